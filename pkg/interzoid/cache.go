@@ -0,0 +1,99 @@
+package interzoid
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// responseCache holds successful response bodies for a fixed time, bounded
+// to maxEntries and evicting the least recently used entry first. A nil
+// cache stores nothing.
+type responseCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // of *cachedResponse, least recently used first
+}
+
+type cachedResponse struct {
+	key     string
+	body    []byte
+	expires time.Time
+}
+
+func newResponseCache(ttl time.Duration, maxEntries int) *responseCache {
+	if ttl <= 0 || maxEntries <= 0 {
+		return nil
+	}
+	return &responseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// cacheable reports whether responses of endpoint may be cached. The credit
+// balance changes with every call, so a cached one would be stale.
+func cacheable(endpoint string) bool {
+	return endpoint != remainingCreditsEndpoint
+}
+
+// cacheKey identifies a request. Responses are kept per API key, so that
+// one caller is never served a lookup another caller paid for, and the key
+// is hashed so that keys are not held in memory longer than the client
+// itself holds them.
+func cacheKey(apiKey, rawURL string) string {
+	sum := sha256.Sum256([]byte(apiKey + "\x00" + rawURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns the cached body for key, if it has not expired.
+func (c *responseCache) get(key string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*cachedResponse)
+	if time.Now().After(entry.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToBack(el)
+	return entry.body, true
+}
+
+// put caches body under key, evicting the least recently used entries
+// beyond maxEntries.
+func (c *responseCache) put(key string, body []byte) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cachedResponse{key: key, body: body, expires: time.Now().Add(c.ttl)}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.order.MoveToBack(el)
+		return
+	}
+	c.entries[key] = c.order.PushBack(entry)
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
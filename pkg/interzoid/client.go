@@ -0,0 +1,230 @@
+// Package interzoid is a Go client for the Interzoid data quality, matching,
+// enrichment and standardization APIs.
+//
+// It is the client the Interzoid MCP server uses, so calls made through it
+// behave exactly as the server's tools do: the API key is sent as the
+// x-api-key header, a call without a key starts the x402 payment flow, and
+// failures are classified into the same error codes.
+//
+//	client := interzoid.NewClient(interzoid.WithAPIKey(os.Getenv("INTERZOID_API_KEY")))
+//	match, err := client.CompanyMatch(ctx, interzoid.CompanyMatchRequest{Company: "IBM"})
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Println(match.SimKey)
+package interzoid
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// CLIENT
+// ============================================================================
+//
+// A Client is safe for concurrent use. Options set the base URL, API key and
+// HTTP client, and enable retries and a response cache; all are off or at
+// the Interzoid defaults unless given.
+// ============================================================================
+
+const (
+	// DefaultBaseURL is the Interzoid API.
+	DefaultBaseURL = "https://api.interzoid.com"
+
+	// DefaultTimeout bounds each request made with the default HTTP client.
+	DefaultTimeout = 30 * time.Second
+)
+
+// Client calls the Interzoid API.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+
+	maxRetries int
+	backoff    time.Duration
+
+	cache *responseCache
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL sends requests to baseURL instead of DefaultBaseURL.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) { c.baseURL = strings.TrimRight(baseURL, "/") }
+}
+
+// WithAPIKey authenticates requests with an Interzoid API key. Without one,
+// requests are paid per call with x402 and answered with CodePaymentRequired
+// errors carrying the payment requirements.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithHTTPClient makes requests with hc, for custom timeouts, transports or
+// proxies.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithRetry retries failures that may succeed on a later attempt (see
+// Error.Retryable) up to maxRetries times. The wait starts at backoff and
+// doubles on each attempt, with jitter; a Retry-After from the API is used
+// when it is longer.
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// WithCache keeps successful responses for ttl and answers identical
+// requests (same endpoint, parameters and API key) from memory. Cached
+// answers cost no credits. At most maxEntries responses are kept, dropping
+// the least recently used first. The credit balance is never cached, since
+// it changes with every call.
+func WithCache(ttl time.Duration, maxEntries int) Option {
+	return func(c *Client) { c.cache = newResponseCache(ttl, maxEntries) }
+}
+
+// NewClient returns a client configured by opts.
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		baseURL:    DefaultBaseURL,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithKey returns a client that shares c's settings, HTTP client and cache
+// but authenticates with key. An empty key makes x402 calls.
+func (c *Client) WithKey(key string) *Client {
+	clone := *c
+	clone.apiKey = key
+	return &clone
+}
+
+// Call makes a GET request to an Interzoid endpoint, such as
+// "/getcompanymatchadvanced", and returns the decoded JSON response. Use it
+// for endpoints without a typed method.
+func (c *Client) Call(ctx context.Context, endpoint string, params url.Values) (map[string]interface{}, error) {
+	body, status, err := c.get(ctx, endpoint, params)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, malformedError(status, err)
+	}
+	return result, nil
+}
+
+// Cached returns the response the client's cache holds for a call to
+// endpoint with params, without making a request. It reports false when
+// the client has no cache or the response is not in it.
+func (c *Client) Cached(endpoint string, params url.Values) (map[string]interface{}, bool) {
+	u, err := c.url(endpoint, params)
+	if err != nil || !cacheable(endpoint) {
+		return nil, false
+	}
+	body, ok := c.cache.get(cacheKey(c.apiKey, u))
+	if !ok {
+		return nil, false
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, false
+	}
+	return result, true
+}
+
+// url returns the URL of a call to endpoint with params.
+func (c *Client) url(endpoint string, params url.Values) (string, error) {
+	u, err := url.Parse(c.baseURL + endpoint)
+	if err != nil {
+		return "", &Error{Code: CodeInvalidInput, Message: "invalid endpoint URL: " + err.Error(), Err: err}
+	}
+	u.RawQuery = params.Encode()
+	return u.String(), nil
+}
+
+// get returns the body of a successful response, retrying and caching as
+// configured.
+func (c *Client) get(ctx context.Context, endpoint string, params url.Values) ([]byte, int, error) {
+	u, err := c.url(endpoint, params)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cache := c.cache
+	if !cacheable(endpoint) {
+		cache = nil
+	}
+	key := cacheKey(c.apiKey, u)
+	if body, ok := cache.get(key); ok {
+		return body, http.StatusOK, nil
+	}
+
+	for attempt := 0; ; attempt++ {
+		body, status, err := c.do(ctx, u)
+		if err == nil {
+			cache.put(key, body)
+			return body, status, nil
+		}
+
+		apiErr, ok := err.(*Error)
+		if !ok || !apiErr.Retryable() || attempt >= c.maxRetries {
+			return nil, status, err
+		}
+		wait := c.backoff << attempt
+		wait += time.Duration(rand.Int63n(int64(wait)/2 + 1))
+		if apiErr.RetryAfter > wait {
+			wait = apiErr.RetryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return nil, status, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// do makes one request.
+func (c *Client) do(ctx context.Context, rawURL string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, 0, &Error{Code: CodeInvalidInput, Message: "failed to create request: " + err.Error(), Err: err}
+	}
+
+	// Send API key via x-api-key header (matching Interzoid API convention)
+	// Omitting it triggers the x402 payment flow
+	if c.apiKey != "" {
+		req.Header.Set("x-api-key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, transportError("API request failed", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, transportError("failed to read response", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp.StatusCode, responseError(resp, body, c.apiKey)
+	}
+	return body, resp.StatusCode, nil
+}
@@ -0,0 +1,246 @@
+package interzoid
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testServer serves handler and returns a client for it, with opts, and a
+// count of the requests made.
+func testServer(t *testing.T, handler http.HandlerFunc, opts ...Option) (*Client, func() int) {
+	t.Helper()
+	var calls atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	client := NewClient(append([]Option{WithBaseURL(ts.URL)}, opts...)...)
+	return client, func() int { return int(calls.Load()) }
+}
+
+func TestCompanyMatch(t *testing.T) {
+	var got *http.Request
+	client, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		io.WriteString(w, `{"SimKey":"N1Ai4RfV0SRJf2dJwDO0Cvzh4xCgQG","Code":"Success","Credits":"4281","Extra":7}`)
+	}, WithAPIKey("test-key"))
+
+	match, err := client.CompanyMatch(context.Background(), CompanyMatchRequest{Company: " IBM "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.URL.Path != "/getcompanymatchadvanced" || got.URL.Query().Get("company") != "IBM" {
+		t.Errorf("request = %s", got.URL)
+	}
+	if got.URL.Query().Has("algorithm") {
+		t.Errorf("algorithm = %q sent, want none so Interzoid picks its default", got.URL.Query().Get("algorithm"))
+	}
+	if key := got.Header.Get("x-api-key"); key != "test-key" {
+		t.Errorf("x-api-key = %q", key)
+	}
+	if match.SimKey != "N1Ai4RfV0SRJf2dJwDO0Cvzh4xCgQG" || match.Code != "Success" || match.Credits != 4281 {
+		t.Errorf("response = %+v", match)
+	}
+	if match.Field("Extra") != "7" || match.Field("Missing") != "" {
+		t.Errorf("Field(Extra) = %q, Field(Missing) = %q", match.Field("Extra"), match.Field("Missing"))
+	}
+}
+
+func TestMissingRequiredParameter(t *testing.T) {
+	client, calls := testServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	_, err := client.OrgMatchScore(context.Background(), OrgMatchScoreRequest{Org1: "IBM"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Code != CodeInvalidInput {
+		t.Fatalf("err = %v, want invalid_input", err)
+	}
+	if calls() != 0 {
+		t.Errorf("%d requests sent for an invalid request", calls())
+	}
+}
+
+func TestPaymentRequired(t *testing.T) {
+	client, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "" {
+			t.Error("an x-api-key header was sent without a key")
+		}
+		w.WriteHeader(http.StatusPaymentRequired)
+		io.WriteString(w, `{"x402Version":1,"accepts":[{"scheme":"exact","network":"base"}]}`)
+	})
+
+	_, err := client.Gender(context.Background(), NameRequest{Name: "Maria"})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Code != CodePaymentRequired || apiErr.StatusCode != http.StatusPaymentRequired {
+		t.Fatalf("err = %v, want payment_required", err)
+	}
+	if apiErr.PaymentRequirements["x402Version"] != float64(1) {
+		t.Errorf("payment requirements = %v", apiErr.PaymentRequirements)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var attempts atomic.Int64
+	client, calls := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"Gender":"F","Code":"Success"}`)
+	}, WithAPIKey("key"), WithRetry(2, time.Millisecond))
+
+	result, err := client.Gender(context.Background(), NameRequest{Name: "Maria"})
+	if err != nil || result.Field("Gender") != "F" {
+		t.Fatalf("Gender = %+v, %v", result, err)
+	}
+	if calls() != 3 {
+		t.Errorf("%d requests, want 3", calls())
+	}
+
+	// Failures that would fail again are not retried
+	client, calls = testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}, WithAPIKey("key"), WithRetry(2, time.Millisecond))
+	if _, err := client.Gender(context.Background(), NameRequest{Name: "Maria"}); err == nil || calls() != 1 {
+		t.Errorf("auth failure: err %v after %d requests, want one failed request", err, calls())
+	}
+}
+
+func TestCache(t *testing.T) {
+	client, calls := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"Gender":"F","Code":"Success"}`)
+	}, WithAPIKey("key"), WithCache(time.Minute, 10))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Gender(context.Background(), NameRequest{Name: "Maria"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls() != 1 {
+		t.Errorf("%d requests for the same lookup, want 1", calls())
+	}
+
+	// Another key shares the cache but not the answers
+	if _, err := client.WithKey("other").Gender(context.Background(), NameRequest{Name: "Maria"}); err != nil {
+		t.Fatal(err)
+	}
+	if calls() != 2 {
+		t.Errorf("%d requests after a lookup with another key, want 2", calls())
+	}
+}
+
+// The credit balance changes with every call, so it is never cached.
+func TestCacheSkipsCredits(t *testing.T) {
+	client, calls := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"Credits":"100","Code":"Success"}`)
+	}, WithAPIKey("key"), WithCache(time.Minute, 10))
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.RemainingCredits(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls() != 2 {
+		t.Errorf("%d requests for two balance checks, want 2", calls())
+	}
+	if _, ok := client.Cached("/getremainingcredits", nil); ok {
+		t.Error("the balance was cached")
+	}
+}
+
+func TestCall(t *testing.T) {
+	client, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `not json`)
+	})
+	_, err := client.Call(context.Background(), "/getgender", nil)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.Code != CodeMalformedResponse || apiErr.StatusCode != http.StatusOK {
+		t.Errorf("err = %v, want malformed_response", err)
+	}
+}
+
+func TestTypedResponses(t *testing.T) {
+	client, _ := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"Code":"Success","Credits":"10","Standard":"IBM","Score":"87","Reasoning":"registered","Rate":"0.9213","TempC":"21.5","Gender":"F"}`)
+	}, WithAPIKey("key"))
+	ctx := context.Background()
+
+	standard, err := client.OrgStandard(ctx, OrgStandardRequest{Org: "ibm corp"})
+	if err != nil || standard.Standard != "IBM" {
+		t.Errorf("OrgStandard = %+v, %v", standard, err)
+	}
+	trust, err := client.EmailTrustScore(ctx, LookupRequest{Lookup: "ann@example.com"})
+	if err != nil || trust.Score != 87 || trust.Reasoning != "registered" {
+		t.Errorf("EmailTrustScore = %+v, %v", trust, err)
+	}
+	rate, err := client.CurrencyRate(ctx, CurrencyRateRequest{From: "USD", To: "EUR"})
+	if err != nil || rate.Rate != 0.9213 {
+		t.Errorf("CurrencyRate = %+v, %v", rate, err)
+	}
+	weather, err := client.GlobalWeather(ctx, WeatherRequest{Location: "Paris"})
+	if err != nil || weather.TempC != 21.5 {
+		t.Errorf("GlobalWeather = %+v, %v", weather, err)
+	}
+	gender, err := client.Gender(ctx, NameRequest{Name: "Maria"})
+	if err != nil || gender.Gender != "F" || gender.Credits != 10 {
+		t.Errorf("Gender = %+v, %v", gender, err)
+	}
+}
+
+func TestCached(t *testing.T) {
+	client, calls := testServer(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"Gender":"F","Code":"Success"}`)
+	}, WithAPIKey("key"), WithCache(time.Minute, 10))
+	params := url.Values{"name": {"Maria"}}
+
+	if _, ok := client.Cached("/getgender", params); ok {
+		t.Fatal("a response was cached before any call")
+	}
+	if _, err := client.Call(context.Background(), "/getgender", params); err != nil {
+		t.Fatal(err)
+	}
+	result, ok := client.Cached("/getgender", params)
+	if !ok || result["Gender"] != "F" {
+		t.Errorf("Cached = %v, %v", result, ok)
+	}
+	if _, ok := client.WithKey("other").Cached("/getgender", params); ok {
+		t.Error("another key was given the cached response")
+	}
+	if calls() != 1 {
+		t.Errorf("%d requests, want 1", calls())
+	}
+}
+
+func TestResponseCacheEviction(t *testing.T) {
+	c := newResponseCache(time.Hour, 2)
+	c.put("a", []byte("A"))
+	c.put("b", []byte("B"))
+
+	// a was used more recently than b, so b is evicted
+	c.get("a")
+	c.put("d", []byte("D"))
+	if _, ok := c.get("b"); ok {
+		t.Error("least recently used entry was not evicted")
+	}
+	if body, ok := c.get("a"); !ok || string(body) != "A" {
+		t.Error("recently used entry was evicted")
+	}
+
+	c = newResponseCache(time.Millisecond, 10)
+	c.put("a", []byte("A"))
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := c.get("a"); ok {
+		t.Error("expired entry was returned")
+	}
+
+	if c := newResponseCache(0, 10); c != nil {
+		t.Error("a zero TTL made a cache")
+	}
+}
@@ -0,0 +1,517 @@
+package interzoid
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// ENDPOINTS
+// ============================================================================
+//
+// One method per Interzoid API, each taking a request struct whose fields
+// are the API's query parameters (tagged `url:"name"`; `,required` fields
+// must be non-empty, and other empty fields are left out). Responses embed Response, which carries the Code and Credits
+// fields every API returns and the full decoded body in Raw. Each family of
+// APIs returning the same answer has a response type with typed fields for
+// it; fields without one are read with Field.
+//
+// Algorithm fields are optional and select the model variant. Empty sends
+// no algorithm, so Interzoid picks its default, as the MCP server's tools
+// do.
+// ============================================================================
+
+// Number decodes a JSON number or numeric string, since Interzoid returns
+// numbers such as Credits and Score as strings.
+type Number int64
+
+func (n *Number) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("interzoid: %s is not a number", data)
+	}
+	*n = Number(v)
+	return nil
+}
+
+// Float decodes a JSON number or numeric string with a fractional part,
+// such as an exchange rate or temperature.
+type Float float64
+
+func (f *Float) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*f = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("interzoid: %s is not a number", data)
+	}
+	*f = Float(v)
+	return nil
+}
+
+// Response holds the fields common to every Interzoid response.
+type Response struct {
+	Code    string `json:"Code"`    // "Success" on success
+	Credits Number `json:"Credits"` // remaining credits for the API key
+
+	// Raw is the whole decoded response, including fields without a typed
+	// counterpart.
+	Raw map[string]interface{} `json:"-"`
+}
+
+func (r *Response) setRaw(raw map[string]interface{}) { r.Raw = raw }
+
+// Field returns a response field as a string, or "" if it is absent.
+func (r *Response) Field(name string) string {
+	switch v := r.Raw[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// SimKeyResponse is the result of a matching API: records whose similarity
+// keys are equal refer to the same entity.
+type SimKeyResponse struct {
+	Response
+	SimKey string `json:"SimKey"`
+}
+
+// ScoreResponse is the result of a match-score API, from 0 to 100.
+type ScoreResponse struct {
+	Response
+	Score Number `json:"Score"`
+}
+
+// ---------------------------------------------------------------------------
+// Data Matching
+// ---------------------------------------------------------------------------
+
+type CompanyMatchRequest struct {
+	Company   string `url:"company,required"`
+	Algorithm string `url:"algorithm"`
+}
+
+type FullNameMatchRequest struct {
+	FullName string `url:"fullname,required"`
+}
+
+type AddressMatchRequest struct {
+	Address   string `url:"address,required"`
+	Algorithm string `url:"algorithm"`
+}
+
+type GlobalAddressMatchRequest struct {
+	Address string `url:"address,required"`
+}
+
+type ProductMatchRequest struct {
+	Product   string `url:"product,required"`
+	Algorithm string `url:"algorithm"`
+}
+
+type OrgMatchScoreRequest struct {
+	Org1 string `url:"org1,required"`
+	Org2 string `url:"org2,required"`
+}
+
+type FullNameMatchScoreRequest struct {
+	FullName1 string `url:"fullname1,required"`
+	FullName2 string `url:"fullname2,required"`
+}
+
+// CompanyMatch returns the similarity key of a company or organization name.
+func (c *Client) CompanyMatch(ctx context.Context, req CompanyMatchRequest) (*SimKeyResponse, error) {
+	return fetch[SimKeyResponse](ctx, c, "/getcompanymatchadvanced", req)
+}
+
+// FullNameMatch returns the similarity key of a person's name.
+func (c *Client) FullNameMatch(ctx context.Context, req FullNameMatchRequest) (*SimKeyResponse, error) {
+	return fetch[SimKeyResponse](ctx, c, "/getfullnamematch", req)
+}
+
+// AddressMatch returns the similarity key of a US street address.
+func (c *Client) AddressMatch(ctx context.Context, req AddressMatchRequest) (*SimKeyResponse, error) {
+	return fetch[SimKeyResponse](ctx, c, "/getaddressmatchadvanced", req)
+}
+
+// GlobalAddressMatch returns the similarity key of an address in any country.
+func (c *Client) GlobalAddressMatch(ctx context.Context, req GlobalAddressMatchRequest) (*SimKeyResponse, error) {
+	return fetch[SimKeyResponse](ctx, c, "/getglobaladdressmatch", req)
+}
+
+// ProductMatch returns the similarity key of a product name.
+func (c *Client) ProductMatch(ctx context.Context, req ProductMatchRequest) (*SimKeyResponse, error) {
+	return fetch[SimKeyResponse](ctx, c, "/getproductmatch", req)
+}
+
+// OrgMatchScore scores how likely two organization names are the same entity.
+func (c *Client) OrgMatchScore(ctx context.Context, req OrgMatchScoreRequest) (*ScoreResponse, error) {
+	return fetch[ScoreResponse](ctx, c, "/getorgmatchscore", req)
+}
+
+// FullNameMatchScore scores how likely two person names are the same person.
+func (c *Client) FullNameMatchScore(ctx context.Context, req FullNameMatchScoreRequest) (*ScoreResponse, error) {
+	return fetch[ScoreResponse](ctx, c, "/getfullnamematchscore", req)
+}
+
+// ---------------------------------------------------------------------------
+// Data Enrichment (premium)
+// ---------------------------------------------------------------------------
+
+// LookupRequest names what an enrichment API looks up: a company, email
+// address, IP address, phone number or ticker symbol.
+type LookupRequest struct {
+	Lookup string `url:"lookup,required"`
+}
+
+type RecentNewsRequest struct {
+	Topic string `url:"topic,required"`
+}
+
+// TrustScoreResponse is the result of an API that scores a lookup from 0
+// to 99, with the reasoning behind the score.
+type TrustScoreResponse struct {
+	Response
+	Score     Number `json:"Score"`
+	Reasoning string `json:"Reasoning"`
+}
+
+// The other enrichment APIs answer with a profile whose fields depend on
+// what was found for the lookup, so they return Response; read the profile
+// with Field or Raw.
+
+// BusinessInfo returns business intelligence for a company.
+func (c *Client) BusinessInfo(ctx context.Context, req LookupRequest) (*Response, error) {
+	return fetch[Response](ctx, c, "/getbusinessinfo", req)
+}
+
+// CompanyVerification scores whether a company exists and is legitimate.
+func (c *Client) CompanyVerification(ctx context.Context, req LookupRequest) (*TrustScoreResponse, error) {
+	return fetch[TrustScoreResponse](ctx, c, "/getcompanyverification", req)
+}
+
+// EmailTrustScore scores the trustworthiness of an email address.
+func (c *Client) EmailTrustScore(ctx context.Context, req LookupRequest) (*TrustScoreResponse, error) {
+	return fetch[TrustScoreResponse](ctx, c, "/emailtrustscore", req)
+}
+
+// ExecutiveProfile returns the leadership of a company.
+func (c *Client) ExecutiveProfile(ctx context.Context, req LookupRequest) (*Response, error) {
+	return fetch[Response](ctx, c, "/getexecutiveprofile", req)
+}
+
+// IPProfile returns the location, network and reputation of an IP address.
+func (c *Client) IPProfile(ctx context.Context, req LookupRequest) (*Response, error) {
+	return fetch[Response](ctx, c, "/getipprofile", req)
+}
+
+// ParentCompanyInfo returns the parent company of a company or subsidiary.
+func (c *Client) ParentCompanyInfo(ctx context.Context, req LookupRequest) (*Response, error) {
+	return fetch[Response](ctx, c, "/getparentcompanyinfo", req)
+}
+
+// PhoneProfile returns the carrier, line type and risk of a phone number.
+func (c *Client) PhoneProfile(ctx context.Context, req LookupRequest) (*Response, error) {
+	return fetch[Response](ctx, c, "/getphoneprofile", req)
+}
+
+// RecentNews returns recent news about a company or topic.
+func (c *Client) RecentNews(ctx context.Context, req RecentNewsRequest) (*Response, error) {
+	return fetch[Response](ctx, c, "/getrecentnews", req)
+}
+
+// StockInfo returns price and analysis for a ticker symbol.
+func (c *Client) StockInfo(ctx context.Context, req LookupRequest) (*Response, error) {
+	return fetch[Response](ctx, c, "/getstockinfo", req)
+}
+
+// ---------------------------------------------------------------------------
+// Data Standardization
+// ---------------------------------------------------------------------------
+
+type OrgStandardRequest struct {
+	Org string `url:"org,required"`
+}
+
+type CountryRequest struct {
+	Country   string `url:"country,required"`
+	Algorithm string `url:"algorithm"`
+}
+
+type StateAbbreviationRequest struct {
+	State     string `url:"state,required"`
+	Algorithm string `url:"algorithm"`
+}
+
+type CityStandardRequest struct {
+	City      string `url:"city,required"`
+	Algorithm string `url:"algorithm"`
+}
+
+// StandardResponse is the result of a standardization API: the canonical
+// form of the value sent.
+type StandardResponse struct {
+	Response
+	Standard string `json:"Standard"`
+}
+
+// StateResponse is the result of the state abbreviation API.
+type StateResponse struct {
+	Response
+	State        string `json:"State"`        // full name
+	Abbreviation string `json:"Abbreviation"` // postal abbreviation
+}
+
+// OrgStandard returns the canonical form of an organization name.
+func (c *Client) OrgStandard(ctx context.Context, req OrgStandardRequest) (*StandardResponse, error) {
+	return fetch[StandardResponse](ctx, c, "/getorgstandard", req)
+}
+
+// CountryStandard returns the canonical form of a country name.
+func (c *Client) CountryStandard(ctx context.Context, req CountryRequest) (*StandardResponse, error) {
+	return fetch[StandardResponse](ctx, c, "/getcountrystandard", req)
+}
+
+// CountryInfo returns the ISO codes, currency and calling code of a country.
+func (c *Client) CountryInfo(ctx context.Context, req CountryRequest) (*Response, error) {
+	return fetch[Response](ctx, c, "/getcountryinfo", req)
+}
+
+// StateAbbreviation returns the full name and abbreviation of a US state or
+// province.
+func (c *Client) StateAbbreviation(ctx context.Context, req StateAbbreviationRequest) (*StateResponse, error) {
+	return fetch[StateResponse](ctx, c, "/getstateabbreviation", req)
+}
+
+// CityStandard returns the canonical form of a city name.
+func (c *Client) CityStandard(ctx context.Context, req CityStandardRequest) (*StandardResponse, error) {
+	return fetch[StandardResponse](ctx, c, "/getcitystandard", req)
+}
+
+// ---------------------------------------------------------------------------
+// Data Enhancement
+// ---------------------------------------------------------------------------
+
+type AddressParseRequest struct {
+	Address string `url:"address,required"`
+}
+
+type EntityTypeRequest struct {
+	Data string `url:"data,required"`
+}
+
+// NameRequest is a person's name, for the gender and name origin APIs.
+type NameRequest struct {
+	Name string `url:"name,required"`
+}
+
+type TextRequest struct {
+	Text string `url:"text,required"`
+}
+
+type TranslateRequest struct {
+	Text string `url:"text,required"`
+	To   string `url:"to,required"` // target language
+}
+
+// GenderResponse is the result of the gender API.
+type GenderResponse struct {
+	Response
+	Gender string `json:"Gender"`
+}
+
+// OriginResponse is the result of the name origin API.
+type OriginResponse struct {
+	Response
+	Origin string `json:"Origin"`
+}
+
+// LanguageResponse is the result of the language identification API.
+type LanguageResponse struct {
+	Response
+	Language string `json:"Language"`
+}
+
+// TranslationResponse is the result of a translation API.
+type TranslationResponse struct {
+	Response
+	Translation string `json:"Translation"`
+}
+
+// EntityTypeResponse is the result of the entity type API.
+type EntityTypeResponse struct {
+	Response
+	EntityType string `json:"EntityType"`
+}
+
+// AddressParse splits an address into its components.
+func (c *Client) AddressParse(ctx context.Context, req AddressParseRequest) (*Response, error) {
+	return fetch[Response](ctx, c, "/addressparse", req)
+}
+
+// EntityType classifies a value as a person, organization, location or other.
+func (c *Client) EntityType(ctx context.Context, req EntityTypeRequest) (*EntityTypeResponse, error) {
+	return fetch[EntityTypeResponse](ctx, c, "/getentitytype", req)
+}
+
+// Gender returns the likely gender associated with a name.
+func (c *Client) Gender(ctx context.Context, req NameRequest) (*GenderResponse, error) {
+	return fetch[GenderResponse](ctx, c, "/getgender", req)
+}
+
+// NameOrigin returns the likely origin of a name.
+func (c *Client) NameOrigin(ctx context.Context, req NameRequest) (*OriginResponse, error) {
+	return fetch[OriginResponse](ctx, c, "/getnameorigin", req)
+}
+
+// IdentifyLanguage returns the language of a text.
+func (c *Client) IdentifyLanguage(ctx context.Context, req TextRequest) (*LanguageResponse, error) {
+	return fetch[LanguageResponse](ctx, c, "/identifylanguage", req)
+}
+
+// TranslateToEnglish translates a text into English.
+func (c *Client) TranslateToEnglish(ctx context.Context, req TextRequest) (*TranslationResponse, error) {
+	return fetch[TranslationResponse](ctx, c, "/translatetoenglish", req)
+}
+
+// TranslateToAny translates a text into the language req.To.
+func (c *Client) TranslateToAny(ctx context.Context, req TranslateRequest) (*TranslationResponse, error) {
+	return fetch[TranslationResponse](ctx, c, "/translatetoany", req)
+}
+
+// ---------------------------------------------------------------------------
+// Utility
+// ---------------------------------------------------------------------------
+
+type CurrencyRateRequest struct {
+	From string `url:"from,required"` // ISO 4217 code
+	To   string `url:"to,required"`   // ISO 4217 code
+}
+
+type WeatherRequest struct {
+	Location string `url:"location,required"`
+}
+
+type ZipCodeRequest struct {
+	Zip string `url:"zip,required"`
+}
+
+// RateResponse is the result of the currency rate API.
+type RateResponse struct {
+	Response
+	Rate Float `json:"Rate"` // units of To per unit of From
+}
+
+// WeatherResponse is the result of the weather API.
+type WeatherResponse struct {
+	Response
+	City    string `json:"City"`
+	TempF   Float  `json:"TempF"`
+	TempC   Float  `json:"TempC"`
+	Weather string `json:"Weather"` // conditions, such as "Partly Cloudy"
+}
+
+// ZipCodeResponse is the result of the ZIP code API.
+type ZipCodeResponse struct {
+	Response
+	City     string `json:"City"`
+	State    string `json:"State"`
+	County   string `json:"County"`
+	TimeZone string `json:"TimeZone"`
+}
+
+// CurrencyRate returns the exchange rate between two currencies.
+func (c *Client) CurrencyRate(ctx context.Context, req CurrencyRateRequest) (*RateResponse, error) {
+	return fetch[RateResponse](ctx, c, "/getrates", req)
+}
+
+// GlobalWeather returns the current weather for a city.
+func (c *Client) GlobalWeather(ctx context.Context, req WeatherRequest) (*WeatherResponse, error) {
+	return fetch[WeatherResponse](ctx, c, "/getglobalweather", req)
+}
+
+// ZipCodeInfo returns the city, state, county and time zone of a US ZIP code.
+func (c *Client) ZipCodeInfo(ctx context.Context, req ZipCodeRequest) (*ZipCodeResponse, error) {
+	return fetch[ZipCodeResponse](ctx, c, "/getzipcodeinfo", req)
+}
+
+// remainingCreditsEndpoint returns the credit balance; it is never cached.
+const remainingCreditsEndpoint = "/getremainingcredits"
+
+// RemainingCredits returns the credit balance of the client's API key in
+// Response.Credits. It costs no credits and is never served from the cache.
+func (c *Client) RemainingCredits(ctx context.Context) (*Response, error) {
+	return fetch[Response](ctx, c, remainingCreditsEndpoint, struct{}{})
+}
+
+// ---------------------------------------------------------------------------
+// Encoding
+// ---------------------------------------------------------------------------
+
+// fetch calls endpoint with the query parameters of req and decodes the
+// response into a T.
+func fetch[T any, PT interface {
+	*T
+	setRaw(map[string]interface{})
+}](ctx context.Context, c *Client, endpoint string, req interface{}) (*T, error) {
+	params, err := queryParams(req)
+	if err != nil {
+		return nil, err
+	}
+	body, status, err := c.get(ctx, endpoint, params)
+	if err != nil {
+		return nil, err
+	}
+
+	out := PT(new(T))
+	var raw map[string]interface{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, malformedError(status, err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, malformedError(status, err)
+	}
+	out.setRaw(raw)
+	return (*T)(out), nil
+}
+
+// queryParams encodes the `url`-tagged string fields of a request struct.
+// Empty fields are left out, and an empty required field is an error.
+func queryParams(req interface{}) (url.Values, error) {
+	params := url.Values{}
+	v := reflect.ValueOf(req)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("url")
+		if tag == "" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		value := strings.TrimSpace(v.Field(i).String())
+		if value == "" {
+			if opts == "required" {
+				return nil, &Error{Code: CodeInvalidInput, Message: "Missing required parameter: " + name}
+			}
+			continue
+		}
+		params.Set(name, value)
+	}
+	return params, nil
+}
@@ -0,0 +1,159 @@
+package interzoid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// ERRORS
+// ============================================================================
+//
+// Every failed call returns an *Error whose Code classifies the failure.
+// Codes are stable; branch on them rather than on messages. Upstream
+// failures are classified from the HTTP status and, when the body has them,
+// the Interzoid Code and Message fields.
+// ============================================================================
+
+// Code identifies a class of failure.
+type Code string
+
+const (
+	CodeInvalidInput        Code = "invalid_input"
+	CodeAuthFailed          Code = "auth_failed"
+	CodeOutOfCredits        Code = "out_of_credits"
+	CodeRateLimited         Code = "rate_limited"
+	CodeUpstreamUnavailable Code = "upstream_unavailable"
+	CodeTimeout             Code = "timeout"
+	CodePaymentRequired     Code = "payment_required"
+	CodeMalformedResponse   Code = "malformed_response"
+)
+
+// Error is a failed Interzoid call.
+type Error struct {
+	Code       Code
+	Message    string
+	StatusCode int           // HTTP status from Interzoid; 0 if it never answered
+	RetryAfter time.Duration // from the Retry-After header, when given
+
+	// PaymentRequirements holds the x402 payment requirements of a
+	// CodePaymentRequired error, when the response listed them.
+	PaymentRequirements map[string]interface{}
+
+	Err error // underlying cause, if any
+}
+
+func (e *Error) Error() string { return e.Message }
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Retryable reports whether the call may succeed unchanged on a later
+// attempt.
+func (e *Error) Retryable() bool {
+	switch e.Code {
+	case CodeRateLimited, CodeUpstreamUnavailable, CodeTimeout:
+		return true
+	}
+	return false
+}
+
+// transportError classifies a request that got no complete response.
+func transportError(action string, err error) *Error {
+	code := CodeUpstreamUnavailable
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout() {
+		code = CodeTimeout
+	}
+	return &Error{Code: code, Message: fmt.Sprintf("%s: %v", action, err), Err: err}
+}
+
+// malformedError reports a response body that could not be decoded.
+func malformedError(status int, err error) *Error {
+	return &Error{
+		Code:       CodeMalformedResponse,
+		Message:    fmt.Sprintf("failed to parse JSON response: %v", err),
+		StatusCode: status,
+		Err:        err,
+	}
+}
+
+// responseError classifies a response other than 200 OK.
+func responseError(resp *http.Response, body []byte, apiKey string) *Error {
+	var fields struct {
+		Code    string
+		Message string
+	}
+	json.Unmarshal(body, &fields)
+
+	detail := strings.TrimSpace(fields.Message)
+	if detail == "" {
+		detail = strings.TrimSpace(fields.Code)
+	}
+	if detail == "" {
+		detail = strings.TrimSpace(string(body))
+	}
+
+	e := &Error{
+		Code:       statusCode(resp.StatusCode, apiKey),
+		Message:    fmt.Sprintf("Interzoid API returned status %d: %s", resp.StatusCode, detail),
+		StatusCode: resp.StatusCode,
+	}
+	if code, ok := codeFromFields(fields.Code + " " + fields.Message); ok {
+		e.Code = code
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+		e.RetryAfter = time.Duration(secs) * time.Second
+	}
+
+	// Without a key, a 402 lists how to pay for the call with x402
+	if e.Code == CodePaymentRequired {
+		json.Unmarshal(body, &e.PaymentRequirements)
+	}
+	return e
+}
+
+// statusCode classifies an upstream HTTP status.
+func statusCode(status int, apiKey string) Code {
+	switch {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return CodeAuthFailed
+	case status == http.StatusPaymentRequired && apiKey != "":
+		// With a key, Interzoid answers 402 when the account has no credits
+		return CodeOutOfCredits
+	case status == http.StatusPaymentRequired:
+		return CodePaymentRequired
+	case status == http.StatusTooManyRequests:
+		return CodeRateLimited
+	case status == http.StatusRequestTimeout, status == http.StatusGatewayTimeout:
+		return CodeTimeout
+	case status >= http.StatusInternalServerError:
+		return CodeUpstreamUnavailable
+	case status >= http.StatusBadRequest:
+		return CodeInvalidInput
+	default:
+		return CodeMalformedResponse
+	}
+}
+
+// codeFromFields recognizes the failures Interzoid names in its Code and
+// Message fields.
+func codeFromFields(text string) (Code, bool) {
+	text = strings.ToLower(text)
+	switch {
+	case strings.Contains(text, "credit"):
+		return CodeOutOfCredits, true
+	case strings.Contains(text, "license"), strings.Contains(text, "api key"),
+		strings.Contains(text, "unauthorized"), strings.Contains(text, "forbidden"):
+		return CodeAuthFailed, true
+	case strings.Contains(text, "rate limit"), strings.Contains(text, "too many"):
+		return CodeRateLimited, true
+	}
+	return "", false
+}
@@ -0,0 +1,56 @@
+package interzoid
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponseError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		apiKey     string
+		retryAfter string
+		want       Code
+	}{
+		{"unauthorized", http.StatusUnauthorized, `{"Code":"Fail"}`, "key", "", CodeAuthFailed},
+		{"forbidden license", http.StatusForbidden, `{"Code":"Fail","Message":"invalid license key"}`, "key", "", CodeAuthFailed},
+		{"402 with a key", http.StatusPaymentRequired, `{"Code":"Fail"}`, "key", "", CodeOutOfCredits},
+		{"402 without a key", http.StatusPaymentRequired, `not json`, "", "", CodePaymentRequired},
+		{"credits in the body", http.StatusBadRequest, `{"Code":"Fail","Message":"no remaining credits"}`, "key", "", CodeOutOfCredits},
+		{"bad request", http.StatusBadRequest, `{"Code":"Fail","Message":"missing parameter"}`, "key", "", CodeInvalidInput},
+		{"rate limited", http.StatusTooManyRequests, `slow down`, "key", "7", CodeRateLimited},
+		{"unavailable", http.StatusBadGateway, ``, "key", "", CodeUpstreamUnavailable},
+		{"gateway timeout", http.StatusGatewayTimeout, ``, "key", "", CodeTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			if tt.retryAfter != "" {
+				rec.Header().Set("Retry-After", tt.retryAfter)
+			}
+			rec.WriteHeader(tt.status)
+			e := responseError(rec.Result(), []byte(tt.body), tt.apiKey)
+			if e.Code != tt.want || e.StatusCode != tt.status {
+				t.Errorf("code %s status %d, want %s %d", e.Code, e.StatusCode, tt.want, tt.status)
+			}
+			if tt.retryAfter != "" && e.RetryAfter != 7*time.Second {
+				t.Errorf("RetryAfter = %v, want 7s", e.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestTransportError(t *testing.T) {
+	if e := transportError("API request failed", fmt.Errorf("dial: %w", context.DeadlineExceeded)); e.Code != CodeTimeout || !e.Retryable() {
+		t.Errorf("deadline: %s, retryable %v", e.Code, e.Retryable())
+	}
+	if e := transportError("API request failed", fmt.Errorf("connection refused")); e.Code != CodeUpstreamUnavailable || e.StatusCode != 0 {
+		t.Errorf("refused: %s, status %d", e.Code, e.StatusCode)
+	}
+}
@@ -0,0 +1,92 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Every tool with an algorithm parameter advertises its variants as an enum,
+// without a default of its own.
+func TestAlgorithmSchema(t *testing.T) {
+	s := server.NewMCPServer(serverName, serverVersion)
+	registerAllTools(s)
+
+	for name, variants := range algorithmVariants {
+		tool := s.GetTool(name)
+		if tool == nil {
+			t.Errorf("%s is not registered", name)
+			continue
+		}
+		prop, _ := tool.Tool.InputSchema.Properties["algorithm"].(map[string]any)
+		enum, _ := prop["enum"].([]string)
+		if strings.Join(enum, ",") != strings.Join(variants, ",") {
+			t.Errorf("%s algorithm enum = %v, want %v", name, prop["enum"], variants)
+		}
+		if _, ok := prop["default"]; ok {
+			t.Errorf("%s algorithm default = %v, want none", name, prop["default"])
+		}
+	}
+}
+
+func TestAlgorithmToolCall(t *testing.T) {
+	tests := []struct {
+		name          string
+		algorithm     interface{}
+		upstream      string
+		wantSent      string
+		wantRequested interface{}
+		wantAlgorithm interface{}
+		wantErr       string
+	}{
+		{name: "omitted"},
+		{name: "normalized", algorithm: "AI-Deep", wantSent: "ai-deep", wantRequested: "ai-deep"},
+		{name: "upstream report kept", algorithm: "ai-plus", upstream: `"Algorithm":"ai-plus-2",`, wantSent: "ai-plus", wantRequested: "ai-plus", wantAlgorithm: "ai-plus-2"},
+		{name: "payment required", algorithm: "ai-plus", upstream: `"status":"payment_required",`, wantSent: "ai-plus"},
+		{name: "unknown rejected", algorithm: "model-v3-wide", wantErr: "must be one of: model-v4-narrow"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent string
+			var hasAlgorithm bool
+			calls := stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+				sent, hasAlgorithm = r.URL.Query().Get("algorithm"), r.URL.Query().Has("algorithm")
+				w.Write([]byte(`{` + tt.upstream + `"SimKey":"k","Code":"Success"}`))
+			})
+
+			args := map[string]interface{}{"company": "IBM"}
+			if tt.algorithm != nil {
+				args["algorithm"] = tt.algorithm
+			}
+			result, err := callToolHandler(context.Background(), t, "interzoid_company_match_advanced", args, http.Header{"Authorization": {"Bearer key"}})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantErr != "" {
+				if !result.IsError || !strings.Contains(resultText(result), tt.wantErr) {
+					t.Errorf("result = %s, want %q", resultText(result), tt.wantErr)
+				}
+				if calls() != 0 {
+					t.Error("the rejected call reached the API")
+				}
+				return
+			}
+
+			var got map[string]interface{}
+			if err := json.Unmarshal([]byte(resultText(result)), &got); err != nil {
+				t.Fatalf("result %s: %v", resultText(result), err)
+			}
+			if got["Algorithm"] != tt.wantAlgorithm || got["RequestedAlgorithm"] != tt.wantRequested {
+				t.Errorf("Algorithm = %v, RequestedAlgorithm = %v, want %v, %v", got["Algorithm"], got["RequestedAlgorithm"], tt.wantAlgorithm, tt.wantRequested)
+			}
+			if sent != tt.wantSent || hasAlgorithm != (tt.wantSent != "") {
+				t.Errorf("sent algorithm = %q (present %v), want %q", sent, hasAlgorithm, tt.wantSent)
+			}
+		})
+	}
+}
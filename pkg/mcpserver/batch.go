@@ -0,0 +1,203 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ============================================================================
+// BATCH ARGUMENTS
+// ============================================================================
+//
+// Any required parameter may be passed as an array instead of a string. The
+// call is then expanded into one upstream call per element. When several
+// parameters are arrays they are paired element by element; a scalar (or a
+// single-element array) is reused for every call.
+// ============================================================================
+
+const (
+	maxBatchSize     = 25 // maximum elements in a batch argument
+	batchConcurrency = 4  // upstream calls in flight per batch
+)
+
+// expandArguments validates the tool arguments and expands them into the
+// query parameters for each upstream call. isBatch reports whether any
+// argument was an array. On failure errMsg describes the problem for the
+// agent.
+func expandArguments(args map[string]interface{}, requiredParams, optionalParams []paramMapping) (calls []map[string]string, isBatch bool, errMsg string) {
+	n := 1
+	lengthFrom := ""
+	values := make(map[string][]interface{}, len(requiredParams))
+
+	for _, p := range requiredParams {
+		raw, ok := args[p.toolName]
+		if !ok {
+			return nil, false, fmt.Sprintf("Missing required parameter: %s", p.toolName)
+		}
+
+		arr, ok := raw.([]interface{})
+		if !ok {
+			values[p.toolName] = []interface{}{raw}
+			continue
+		}
+
+		isBatch = true
+		switch {
+		case len(arr) == 0:
+			return nil, false, fmt.Sprintf("Parameter %s must not be an empty array", p.toolName)
+		case len(arr) > maxBatchSize:
+			return nil, false, fmt.Sprintf("Parameter %s has %d values; at most %d are allowed per call, so split the list into several calls", p.toolName, len(arr), maxBatchSize)
+		case len(arr) > 1 && n > 1 && len(arr) != n:
+			return nil, false, fmt.Sprintf("Array parameters %s and %s must have the same length (got %d and %d)", lengthFrom, p.toolName, n, len(arr))
+		case len(arr) > 1:
+			n = len(arr)
+			lengthFrom = p.toolName
+		}
+		values[p.toolName] = arr
+	}
+
+	// Optional params are always scalars and shared by every call; null
+	// counts as omitted
+	shared := make(map[string]string)
+	for _, p := range optionalParams {
+		raw, ok := args[p.toolName]
+		if !ok || raw == nil {
+			continue
+		}
+		s, ok := coerceArgument(raw)
+		if !ok {
+			return nil, false, fmt.Sprintf("Parameter %s must be a string, number or boolean; only required parameters accept arrays", p.toolName)
+		}
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		norm, err := p.normalize(s)
+		if err != nil {
+			return nil, false, invalidParamMessage(p.toolName, s, err)
+		}
+		shared[p.apiName] = norm
+	}
+
+	calls = make([]map[string]string, n)
+	for i := range calls {
+		params := make(map[string]string, len(requiredParams)+len(shared))
+		for k, v := range shared {
+			params[k] = v
+		}
+
+		for _, p := range requiredParams {
+			vals := values[p.toolName]
+			name := p.toolName
+			raw := vals[0]
+			if len(vals) > 1 {
+				raw = vals[i]
+				name = fmt.Sprintf("%s[%d]", p.toolName, i)
+			}
+
+			s, ok := coerceArgument(raw)
+			if !ok {
+				return nil, false, fmt.Sprintf("Parameter %s must be a string, number or boolean", name)
+			}
+			norm, err := p.normalize(s)
+			if err != nil {
+				return nil, false, invalidParamMessage(name, s, err)
+			}
+			params[p.apiName] = norm
+		}
+		calls[i] = params
+	}
+
+	return calls, isBatch, ""
+}
+
+// allowBatchArguments widens the JSON schema of each required parameter so
+// that clients know it also accepts an array of values.
+func allowBatchArguments(tool *mcp.Tool, requiredParams []paramMapping) {
+	for _, p := range requiredParams {
+		prop, ok := tool.InputSchema.Properties[p.toolName].(map[string]any)
+		if !ok {
+			continue
+		}
+
+		desc, _ := prop["description"].(string)
+		tool.InputSchema.Properties[p.toolName] = map[string]any{
+			"description": fmt.Sprintf("%s. Pass an array of up to %d values to look up several at once (billed per value).", desc, maxBatchSize),
+			"anyOf": []any{
+				map[string]any{"type": "string"},
+				map[string]any{
+					"type":     "array",
+					"items":    map[string]any{"type": "string"},
+					"minItems": 1,
+					"maxItems": maxBatchSize,
+				},
+			},
+		}
+	}
+}
+
+// batchItem is the outcome of one element of a batch call.
+type batchItem struct {
+	Input  map[string]string      `json:"input"`
+	Result map[string]interface{} `json:"result,omitempty"`
+	Error  string                 `json:"error,omitempty"`
+	Code   errorCode              `json:"code,omitempty"` // set with Error, or when payment is required
+	Cached bool                   `json:"cached,omitempty"`
+}
+
+// batchResult is the tool output of a batch call.
+type batchResult struct {
+	Batch     bool        `json:"batch"`
+	Count     int         `json:"count"`
+	Succeeded int         `json:"succeeded"`
+	Failed    int         `json:"failed"`
+	Cached    int         `json:"cached,omitempty"`
+	Results   []batchItem `json:"results"`
+}
+
+// runBatch makes one upstream call per element with bounded concurrency.
+// Individual failures (including rate limiting) are reported per element
+// rather than failing the whole batch.
+func runBatch(ctx context.Context, request mcp.CallToolRequest, endpoint, apiKey string, calls []map[string]string) batchResult {
+	items := make([]batchItem, len(calls))
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+
+	for i, params := range calls {
+		wg.Add(1)
+		go func(i int, params map[string]string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			items[i].Input = params
+			err := ctx.Err()
+			if err == nil {
+				items[i].Result, items[i].Cached, err = callEndpoint(ctx, request, endpoint, apiKey, params)
+			}
+			if err != nil {
+				te := classifyError(err)
+				items[i].Error, items[i].Code = te.message, te.code
+			} else if paymentRequired(items[i].Result) {
+				items[i].Code = codePaymentRequired
+			}
+		}(i, params)
+	}
+	wg.Wait()
+
+	out := batchResult{Batch: true, Count: len(items), Results: items}
+	for _, item := range items {
+		if item.Error != "" || item.Code == codePaymentRequired {
+			out.Failed++
+		} else {
+			out.Succeeded++
+		}
+		if item.Cached {
+			out.Cached++
+		}
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestCoerceArgument(t *testing.T) {
+	tests := []struct {
+		in     interface{}
+		want   string
+		wantOK bool
+	}{
+		{"94105", "94105", true},
+		{float64(94105), "94105", true},
+		{1.5, "1.5", true},
+		{true, "true", true},
+		{json.Number("42"), "42", true},
+		{map[string]interface{}{}, "", false},
+		{nil, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := coerceArgument(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("coerceArgument(%v) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestExpandArguments(t *testing.T) {
+	required := []paramMapping{same("text"), same("to", maxLength(50))}
+	optional := []paramMapping{same("algorithm")}
+
+	tests := []struct {
+		name      string
+		args      map[string]interface{}
+		wantCalls []map[string]string
+		wantBatch bool
+		wantErr   string
+	}{
+		{
+			name:      "scalars",
+			args:      map[string]interface{}{"text": "hello", "to": "French"},
+			wantCalls: []map[string]string{{"text": "hello", "to": "French"}},
+		},
+		{
+			name: "array paired with a reused scalar",
+			args: map[string]interface{}{"text": []interface{}{"hello", "bye"}, "to": "French", "algorithm": "wide"},
+			wantCalls: []map[string]string{
+				{"text": "hello", "to": "French", "algorithm": "wide"},
+				{"text": "bye", "to": "French", "algorithm": "wide"},
+			},
+			wantBatch: true,
+		},
+		{
+			name: "arrays paired element by element",
+			args: map[string]interface{}{"text": []interface{}{"hello", "bye"}, "to": []interface{}{"French", "German"}},
+			wantCalls: []map[string]string{
+				{"text": "hello", "to": "French"},
+				{"text": "bye", "to": "German"},
+			},
+			wantBatch: true,
+		},
+		{name: "missing", args: map[string]interface{}{"text": "hello"}, wantErr: "Missing required parameter: to"},
+		{name: "empty array", args: map[string]interface{}{"text": []interface{}{}, "to": "French"}, wantErr: "must not be an empty array"},
+		{name: "too many", args: map[string]interface{}{"text": make([]interface{}, maxBatchSize+1), "to": "French"}, wantErr: "at most 25"},
+		{name: "mismatched lengths", args: map[string]interface{}{"text": []interface{}{"a", "b"}, "to": []interface{}{"x", "y", "z"}}, wantErr: "must have the same length"},
+		{name: "array for an optional parameter", args: map[string]interface{}{"text": "hello", "to": "French", "algorithm": []interface{}{"wide", "narrow"}}, wantErr: "Parameter algorithm must be a string, number or boolean; only required parameters accept arrays"},
+		{name: "bad element", args: map[string]interface{}{"text": []interface{}{"a", map[string]interface{}{}}, "to": "French"}, wantErr: "text[1] must be a string, number or boolean"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls, isBatch, errMsg := expandArguments(tt.args, required, optional)
+			if tt.wantErr != "" {
+				if !strings.Contains(errMsg, tt.wantErr) {
+					t.Errorf("error = %q, want %q", errMsg, tt.wantErr)
+				}
+				return
+			}
+			if errMsg != "" {
+				t.Fatalf("unexpected error %q", errMsg)
+			}
+			if isBatch != tt.wantBatch {
+				t.Errorf("isBatch = %v, want %v", isBatch, tt.wantBatch)
+			}
+			got, _ := json.Marshal(calls)
+			want, _ := json.Marshal(tt.wantCalls)
+			if string(got) != string(want) {
+				t.Errorf("calls = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestBatchToolCall(t *testing.T) {
+	var failed atomic.Bool
+	calls := stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("company") == "bad" {
+			failed.Store(true)
+			http.Error(w, "no match", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"SimKey":"k","Code":"Success"}`))
+	})
+
+	args := map[string]interface{}{"company": []interface{}{"IBM", "bad", float64(42)}}
+	result, err := callToolHandler(context.Background(), t, "interzoid_company_match_advanced", args, http.Header{"Authorization": {"Bearer key"}})
+	if err != nil || result.IsError {
+		t.Fatalf("call failed: %v %s", err, resultText(result))
+	}
+
+	var got batchResult
+	if err := json.Unmarshal([]byte(resultText(result)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Batch || got.Count != 3 || got.Succeeded != 2 || got.Failed != 1 {
+		t.Errorf("batch = %+v, want 2 of 3 succeeded", got)
+	}
+	if got.Results[1].Error == "" || got.Results[2].Input["company"] != "42" {
+		t.Errorf("results = %+v", got.Results)
+	}
+	if calls() != 3 || !failed.Load() {
+		t.Errorf("upstream calls = %d, want 3", calls())
+	}
+}
+
+func TestBatchPaymentRequired(t *testing.T) {
+	stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		w.Write([]byte(`{"x402Version":1,"accepts":[{"scheme":"exact","network":"base"}]}`))
+	})
+	t.Setenv("INTERZOID_API_KEY", "")
+
+	args := map[string]interface{}{"company": []interface{}{"IBM", "Apple"}}
+	result, err := callToolHandler(context.Background(), t, "interzoid_company_match_advanced", args, nil)
+	if err != nil || result.IsError {
+		t.Fatalf("call failed: %v %s", err, resultText(result))
+	}
+
+	var got batchResult
+	if err := json.Unmarshal([]byte(resultText(result)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Succeeded != 0 || got.Failed != 2 {
+		t.Errorf("batch = %+v, want both calls failed", got)
+	}
+	if got.Results[0].Code != codePaymentRequired || got.Results[0].Result["x402"] != true {
+		t.Errorf("results = %+v, want the payment requirements", got.Results)
+	}
+}
+
+func TestBatchSchema(t *testing.T) {
+	s := server.NewMCPServer(serverName, serverVersion)
+	registerAllTools(s)
+	tool := s.GetTool("interzoid_company_match_advanced")
+	if tool == nil {
+		t.Fatal("tool not registered")
+	}
+	prop, _ := tool.Tool.InputSchema.Properties["company"].(map[string]any)
+	if _, ok := prop["anyOf"]; !ok {
+		t.Errorf("company schema = %v, want string or array", prop)
+	}
+}
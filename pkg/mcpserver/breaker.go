@@ -0,0 +1,290 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// upstreamBreakers holds one circuit breaker per Interzoid endpoint. It holds
+// nil (disabled) when -breaker-failure-ratio is 0, and is replaced when a
+// configuration reload changes the breaker settings.
+var upstreamBreakers atomic.Pointer[breakerSet]
+
+// breakerConfig controls when an endpoint's circuit opens and how it recovers.
+type breakerConfig struct {
+	failureRatio   float64       // fraction of failed calls in a window that opens the circuit
+	minRequests    int           // calls required in a window before the ratio is evaluated
+	window         time.Duration // length of each measurement window; counts restart when one ends
+	openDuration   time.Duration // how long the circuit stays open before probing
+	halfOpenProbes int           // concurrent probe requests allowed while half-open
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker tracks the health of a single Interzoid endpoint.
+//
+//   - closed:    calls pass through; failures are counted in fixed,
+//     back-to-back windows and the circuit opens once the failure ratio of
+//     the current window is exceeded.
+//   - open:      calls fail fast until openDuration has elapsed.
+//   - half-open: a limited number of probe calls are let through; a success
+//     closes the circuit, a failure re-opens it.
+type circuitBreaker struct {
+	mu          sync.Mutex
+	state       breakerState
+	windowStart time.Time
+	successes   int
+	failures    int
+	openedAt    time.Time
+	probes      int
+	lastError   string
+	timesOpened int
+}
+
+// breakerSet lazily creates a breaker for each endpoint it sees.
+type breakerSet struct {
+	cfg breakerConfig
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+func newBreakerSet(cfg breakerConfig) *breakerSet {
+	if cfg.halfOpenProbes < 1 {
+		cfg.halfOpenProbes = 1
+	}
+	return &breakerSet{cfg: cfg, breakers: make(map[string]*circuitBreaker)}
+}
+
+func (bs *breakerSet) get(endpoint string) *circuitBreaker {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	b, ok := bs.breakers[endpoint]
+	if !ok {
+		b = &circuitBreaker{windowStart: time.Now()}
+		bs.breakers[endpoint] = b
+	}
+	return b
+}
+
+// allow reports whether a call to endpoint may proceed. When it may, the
+// returned done func must be called with the outcome of the call. When it
+// may not, a *circuitOpenError describes why and when to retry.
+func (bs *breakerSet) allow(endpoint string) (done func(failure error), err error) {
+	if bs == nil {
+		return func(error) {}, nil
+	}
+
+	b := bs.get(endpoint)
+	now := time.Now()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if wait := bs.cfg.openDuration - now.Sub(b.openedAt); wait > 0 {
+			return nil, &circuitOpenError{endpoint: endpoint, retryAfter: wait, lastError: b.lastError}
+		}
+		b.state = breakerHalfOpen
+		b.probes = 0
+		fallthrough
+
+	case breakerHalfOpen:
+		if b.probes >= bs.cfg.halfOpenProbes {
+			return nil, &circuitOpenError{endpoint: endpoint, lastError: b.lastError, probing: true}
+		}
+		b.probes++
+		return func(failure error) { bs.recordProbe(b, failure) }, nil
+
+	default:
+		if now.Sub(b.windowStart) > bs.cfg.window {
+			b.windowStart = now
+			b.successes = 0
+			b.failures = 0
+		}
+		return func(failure error) { bs.record(b, failure) }, nil
+	}
+}
+
+// record updates a closed breaker with the outcome of a call. A call the
+// caller canceled says nothing about the endpoint, so it is not counted.
+func (bs *breakerSet) record(b *circuitBreaker, failure error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != breakerClosed || errors.Is(failure, context.Canceled) {
+		// The circuit opened while this call was in flight
+		return
+	}
+
+	if failure == nil {
+		b.successes++
+		return
+	}
+
+	b.failures++
+	b.lastError = failure.Error()
+
+	total := b.successes + b.failures
+	if total >= bs.cfg.minRequests && float64(b.failures)/float64(total) >= bs.cfg.failureRatio {
+		b.trip()
+	}
+}
+
+// recordProbe updates a half-open breaker with the outcome of a probe call.
+func (bs *breakerSet) recordProbe(b *circuitBreaker, failure error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != breakerHalfOpen {
+		return
+	}
+	b.probes--
+
+	// A canceled probe frees its slot for the next one
+	if errors.Is(failure, context.Canceled) {
+		return
+	}
+
+	if failure != nil {
+		b.lastError = failure.Error()
+		b.trip()
+		return
+	}
+
+	b.state = breakerClosed
+	b.windowStart = time.Now()
+	b.successes = 0
+	b.failures = 0
+}
+
+// trip opens the circuit. Called with b.mu held.
+func (b *circuitBreaker) trip() {
+	b.state = breakerOpen
+	b.openedAt = time.Now()
+	b.timesOpened++
+}
+
+// breakerStatus is the externally visible state of one endpoint's breaker.
+type breakerStatus struct {
+	Endpoint    string `json:"endpoint"`
+	State       string `json:"state"`
+	Successes   int    `json:"windowSuccesses"`
+	Failures    int    `json:"windowFailures"`
+	TimesOpened int    `json:"timesOpened"`
+	OpenedAt    string `json:"openedAt,omitempty"`
+	LastError   string `json:"lastError,omitempty"`
+}
+
+// snapshot returns the status of every breaker, sorted by endpoint.
+func (bs *breakerSet) snapshot() []breakerStatus {
+	if bs == nil {
+		return []breakerStatus{}
+	}
+
+	bs.mu.Lock()
+	endpoints := make([]string, 0, len(bs.breakers))
+	for endpoint := range bs.breakers {
+		endpoints = append(endpoints, endpoint)
+	}
+	bs.mu.Unlock()
+	sort.Strings(endpoints)
+
+	statuses := make([]breakerStatus, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		b := bs.get(endpoint)
+		b.mu.Lock()
+		st := breakerStatus{
+			Endpoint:    endpoint,
+			State:       b.state.String(),
+			Successes:   b.successes,
+			Failures:    b.failures,
+			TimesOpened: b.timesOpened,
+			LastError:   b.lastError,
+		}
+		if !b.openedAt.IsZero() {
+			st.OpenedAt = b.openedAt.UTC().Format(time.RFC3339)
+		}
+		b.mu.Unlock()
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// circuitOpenError is returned by callInterzoidAPI instead of waiting on an
+// endpoint that is known to be failing.
+type circuitOpenError struct {
+	endpoint   string
+	retryAfter time.Duration
+	lastError  string
+	probing    bool
+}
+
+func (e *circuitOpenError) Error() string {
+	msg := fmt.Sprintf("Interzoid endpoint %s is temporarily unavailable after repeated failures", e.endpoint)
+	if e.lastError != "" {
+		msg += fmt.Sprintf(" (last error: %s)", e.lastError)
+	}
+	if e.probing {
+		return msg + "; a recovery probe is in progress, retry shortly"
+	}
+	return msg + fmt.Sprintf("; retry in %d seconds", int(math.Ceil(e.retryAfter.Seconds())))
+}
+
+var publishBreakers sync.Once
+
+// publishMetrics publishes the circuit breaker state for the /debug/vars
+// metrics endpoint. Main calls it rather than an init function, so programs
+// that embed the server keep the expvar namespace to themselves.
+func publishMetrics() {
+	publishBreakers.Do(func() {
+		expvar.Publish("interzoid_circuit_breakers", expvar.Func(func() any {
+			return upstreamBreakers.Load().snapshot()
+		}))
+	})
+}
+
+// upstreamStatusHandler reports the circuit breaker state of every Interzoid
+// endpoint called since the server started. It makes no upstream calls.
+func upstreamStatusHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	breakers := upstreamBreakers.Load()
+	status := map[string]interface{}{
+		"circuitBreakerEnabled": breakers != nil,
+		"endpoints":             breakers.snapshot(),
+	}
+
+	jsonBytes, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return toolErrorResult(fmt.Errorf("failed to format response: %w", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
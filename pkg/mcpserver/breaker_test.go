@@ -0,0 +1,159 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func testBreakers() *breakerSet {
+	return newBreakerSet(breakerConfig{
+		failureRatio:   0.5,
+		minRequests:    4,
+		window:         time.Minute,
+		openDuration:   time.Minute,
+		halfOpenProbes: 1,
+	})
+}
+
+// call makes one call through the breaker for endpoint with the given
+// outcome, and returns the error allow gave, if any.
+func (bs *breakerSet) call(endpoint string, failure error) error {
+	done, err := bs.allow(endpoint)
+	if err != nil {
+		return err
+	}
+	done(failure)
+	return nil
+}
+
+func TestBreakerOpensOnFailureRatio(t *testing.T) {
+	bs := testBreakers()
+	failure := errors.New("status 503")
+
+	// Below minRequests the ratio is not evaluated
+	bs.call("/getbusinessinfo", failure)
+	bs.call("/getbusinessinfo", failure)
+	bs.call("/getbusinessinfo", nil)
+	if err := bs.call("/getbusinessinfo", failure); err != nil {
+		t.Fatalf("call before the circuit opened failed fast: %v", err)
+	}
+
+	err := bs.call("/getbusinessinfo", nil)
+	var open *circuitOpenError
+	if !errors.As(err, &open) {
+		t.Fatalf("err = %v, want a circuit open error", err)
+	}
+	if open.retryAfter <= 0 || open.lastError != "status 503" {
+		t.Errorf("open error = %+v", open)
+	}
+
+	// Other endpoints are unaffected
+	if err := bs.call("/getgender", nil); err != nil {
+		t.Errorf("another endpoint failed fast: %v", err)
+	}
+}
+
+func TestBreakerWindowRestarts(t *testing.T) {
+	bs := testBreakers()
+	for i := 0; i < 3; i++ {
+		bs.call("/getgender", errors.New("timeout"))
+	}
+	// The window ends before the fourth call, so its counts start over
+	bs.get("/getgender").windowStart = time.Now().Add(-2 * time.Minute)
+	if err := bs.call("/getgender", errors.New("timeout")); err != nil {
+		t.Fatal(err)
+	}
+	if b := bs.get("/getgender"); b.state != breakerClosed || b.failures != 1 {
+		t.Errorf("state = %v with %d failures, want closed with 1", b.state, b.failures)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		outcome error
+		want    breakerState
+	}{
+		{"successful probe closes", nil, breakerClosed},
+		{"failed probe reopens", errors.New("status 500"), breakerOpen},
+		{"canceled probe stays half-open", fmt.Errorf("API request failed: %w", context.Canceled), breakerHalfOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bs := testBreakers()
+			b := bs.get("/getgender")
+			b.trip()
+			b.openedAt = time.Now().Add(-2 * time.Minute)
+
+			done, err := bs.allow("/getgender")
+			if err != nil {
+				t.Fatalf("probe refused: %v", err)
+			}
+			// Only one probe at a time
+			if _, err := bs.allow("/getgender"); err == nil {
+				t.Error("second concurrent probe allowed")
+			}
+			done(tt.outcome)
+			if b.state != tt.want {
+				t.Errorf("state = %v, want %v", b.state, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerIgnoresCanceledCalls(t *testing.T) {
+	bs := testBreakers()
+	for i := 0; i < 10; i++ {
+		bs.call("/getgender", fmt.Errorf("API request failed: %w", context.Canceled))
+	}
+	if b := bs.get("/getgender"); b.state != breakerClosed || b.failures != 0 {
+		t.Errorf("state = %v with %d failures, want closed with none", b.state, b.failures)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	var bs *breakerSet
+	for i := 0; i < 20; i++ {
+		if err := bs.call("/getgender", errors.New("status 500")); err != nil {
+			t.Fatalf("a nil breaker set failed fast: %v", err)
+		}
+	}
+	if got := bs.snapshot(); len(got) != 0 {
+		t.Errorf("snapshot = %v, want empty", got)
+	}
+}
+
+// Once an endpoint's circuit opens, tool calls fail without reaching it.
+func TestBreakerFailsToolCallsFast(t *testing.T) {
+	calls := stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		io.WriteString(w, "unavailable")
+	})
+	upstreamBreakers.Store(testBreakers())
+	t.Cleanup(func() { upstreamBreakers.Store(nil) })
+
+	args := map[string]interface{}{"name": "Maria"}
+	for i := 0; i < 4; i++ {
+		callToolHandler(context.Background(), t, "interzoid_gender", args, nil)
+	}
+	result, err := callToolHandler(context.Background(), t, "interzoid_gender", args, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError {
+		t.Fatal("call to an open circuit succeeded")
+	}
+	if calls() != 4 {
+		t.Errorf("upstream calls = %d, want 4", calls())
+	}
+
+	states := upstreamBreakers.Load().snapshot()
+	if len(states) != 1 || states[0].State != "open" {
+		t.Errorf("snapshot = %+v, want /getgender open", states)
+	}
+}
@@ -0,0 +1,257 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============================================================================
+// CALL SUBCOMMAND
+// ============================================================================
+//
+// interzoid-mcp-server call <tool> --arg name=value ...
+//
+// Runs a tool through the same handler and middleware an MCP client would
+// reach and prints the result the agent would see. The API key comes from
+// INTERZOID_API_KEY or the configuration file.
+// ============================================================================
+
+// argFlags collects repeated --arg name=value flags. Repeating a name passes
+// an array, which makes a batch call.
+type argFlags map[string][]string
+
+func (a argFlags) String() string { return "" }
+
+func (a argFlags) Set(v string) error {
+	name, value, ok := strings.Cut(v, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got %q", v)
+	}
+	a[name] = append(a[name], value)
+	return nil
+}
+
+// arguments converts the flags into tool arguments.
+func (a argFlags) arguments() map[string]interface{} {
+	args := make(map[string]interface{}, len(a))
+	for name, values := range a {
+		if len(values) == 1 {
+			args[name] = values[0]
+			continue
+		}
+		arr := make([]interface{}, len(values))
+		for i, v := range values {
+			arr[i] = v
+		}
+		args[name] = arr
+	}
+	return args
+}
+
+func runCallCommand(args []string) int {
+	fs := flag.NewFlagSet("call", flag.ContinueOnError)
+	toolArgs := argFlags{}
+	fs.Var(toolArgs, "arg", "Tool argument as name=value (repeat for each argument; repeat a name to batch)")
+	format := fs.String("format", "json", "Output format: json or table")
+	cfg, err := loadConfigFlag(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	addCallFlags(fs, cfg)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: interzoid-mcp-server call <tool> [--arg name=value ...] [--format json|table]")
+		fs.PrintDefaults()
+	}
+
+	// Accept the tool name before or after the flags
+	var toolName string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		toolName, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if toolName == "" && fs.NArg() > 0 {
+		toolName = fs.Arg(0)
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return 2
+		}
+	}
+	if toolName == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	if *format != "json" && *format != "table" {
+		fmt.Fprintf(os.Stderr, "Unknown format: %s (use 'json' or 'table')\n", *format)
+		return 2
+	}
+
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer cfg.apply()()
+
+	s, _ := buildServer(cfg.Tools, nil)
+	if s.GetTool(toolName) == nil {
+		fmt.Fprintf(os.Stderr, "Unknown tool: %s\n", toolName)
+		return 2
+	}
+
+	result, err := callTool(context.Background(), s, toolName, toolArgs.arguments())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	text := resultText(result)
+	if result.IsError {
+		fmt.Fprintln(os.Stderr, text)
+		return 1
+	}
+
+	if *format != "table" || writeResultTable(os.Stdout, text) != nil {
+		fmt.Println(text)
+	}
+
+	if lookupFailed(text) {
+		return 1
+	}
+	return 0
+}
+
+// lookupFailed reports whether a successful tool result still holds a
+// failed lookup: an x402 payment_required answer, or a batch with failed
+// values. A batch succeeds as a whole even when some of its lookups fail.
+func lookupFailed(text string) bool {
+	var result map[string]interface{}
+	if json.Unmarshal([]byte(text), &result) != nil {
+		return false
+	}
+	if paymentRequired(result) {
+		return true
+	}
+	var batch batchResult
+	return json.Unmarshal([]byte(text), &batch) == nil && batch.Failed > 0
+}
+
+// callTool calls a tool through s.HandleMessage, exactly as a tools/call
+// over MCP would, so the call passes through the server's middleware.
+func callTool(ctx context.Context, s *server.MCPServer, name string, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	message, err := json.Marshal(mcp.JSONRPCRequest{
+		JSONRPC: mcp.JSONRPC_VERSION,
+		ID:      mcp.NewRequestId(1),
+		Request: mcp.Request{Method: string(mcp.MethodToolsCall)},
+		Params: map[string]interface{}{
+			"name":      name,
+			"arguments": args,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch response := s.HandleMessage(ctx, message).(type) {
+	case mcp.JSONRPCResponse:
+		if result, ok := response.Result.(*mcp.CallToolResult); ok {
+			return result, nil
+		}
+	case mcp.JSONRPCError:
+		return nil, errors.New(response.Error.Message)
+	}
+	return nil, errors.New("unexpected tool response")
+}
+
+// resultText joins the text content of a tool result.
+func resultText(result *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range result.Content {
+		if t, ok := c.(mcp.TextContent); ok {
+			parts = append(parts, t.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// writeResultTable writes a JSON tool result as a table: one field per row
+// for a single result, or one row per element for a batch result.
+func writeResultTable(w io.Writer, text string) error {
+	var single map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &single); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	var batch batchResult
+	if single["batch"] == true && json.Unmarshal([]byte(text), &batch) == nil {
+		var inputs, fields []string
+		for _, item := range batch.Results {
+			inputs = mergeKeys(inputs, item.Input)
+			fields = mergeKeys(fields, item.Result)
+		}
+		fmt.Fprintln(tw, strings.Join(append(append(inputs, fields...), "error"), "\t"))
+		for _, item := range batch.Results {
+			var row []string
+			for _, k := range inputs {
+				row = append(row, item.Input[k])
+			}
+			for _, k := range fields {
+				row = append(row, tableCell(item.Result[k]))
+			}
+			fmt.Fprintln(tw, strings.Join(append(row, item.Error), "\t"))
+		}
+		return tw.Flush()
+	}
+
+	for _, k := range mergeKeys(nil, single) {
+		fmt.Fprintf(tw, "%s\t%s\n", k, tableCell(single[k]))
+	}
+	return tw.Flush()
+}
+
+// mergeKeys adds the keys of m that are not already in keys, in sorted
+// order.
+func mergeKeys[V any](keys []string, m map[string]V) []string {
+	var added []string
+	for k := range m {
+		found := false
+		for _, existing := range keys {
+			if existing == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			added = append(added, k)
+		}
+	}
+	sort.Strings(added)
+	return append(keys, added...)
+}
+
+// tableCell formats a result value for a table cell.
+func tableCell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
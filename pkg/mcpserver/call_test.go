@@ -0,0 +1,146 @@
+package mcpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestArgFlags(t *testing.T) {
+	args := argFlags{}
+	for _, v := range []string{"zip=94105", "zip=10001", "name=a=b", "empty="} {
+		if err := args.Set(v); err != nil {
+			t.Fatalf("Set(%q) = %v", v, err)
+		}
+	}
+	for _, v := range []string{"zip", "=94105"} {
+		if err := args.Set(v); err == nil {
+			t.Errorf("Set(%q) accepted", v)
+		}
+	}
+
+	want := map[string]interface{}{
+		"zip":   []interface{}{"94105", "10001"},
+		"name":  "a=b",
+		"empty": "",
+	}
+	if got := args.arguments(); !reflect.DeepEqual(got, want) {
+		t.Errorf("arguments() = %v, want %v", got, want)
+	}
+}
+
+// callTool goes through the server's middleware, which takes the API key
+// from the headers of a connection-oriented transport.
+func TestCallToolAppliesMiddleware(t *testing.T) {
+	t.Setenv("INTERZOID_API_KEY", "")
+	var apiKey string
+	stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("x-api-key")
+		w.Write([]byte(`{"Gender":"F","Code":"Success"}`))
+	})
+
+	ctx := context.WithValue(context.Background(), connHeaderKey, http.Header{"Authorization": {"Bearer conn-key"}})
+	result, err := callTool(ctx, newTestServer(), "interzoid_gender", map[string]interface{}{"name": "Maria"})
+	if err != nil || result.IsError {
+		t.Fatalf("callTool = %v, %v", resultText(result), err)
+	}
+	if apiKey != "conn-key" {
+		t.Errorf("upstream API key = %q, want the connection's key", apiKey)
+	}
+}
+
+func TestCallToolErrors(t *testing.T) {
+	previous := inboundLimiter.Load()
+	inboundLimiter.Store(newRateLimiter(1, 1, false))
+	t.Cleanup(func() { inboundLimiter.Store(previous) })
+	stubUpstream(t, answer(`{"Gender":"F","Code":"Success"}`))
+	s := newTestServer()
+	args := map[string]interface{}{"name": "Maria"}
+
+	if _, err := callTool(context.Background(), s, "interzoid_gender", args); err != nil {
+		t.Fatalf("first call = %v", err)
+	}
+	result, err := callTool(context.Background(), s, "interzoid_gender", args)
+	if err != nil || !result.IsError || parseToolError(resultText(result)).Code != codeRateLimited {
+		t.Errorf("rate limited call = %v, %v, want a rate_limited tool error", resultText(result), err)
+	}
+	if _, err := callTool(context.Background(), s, "interzoid_nope", args); err == nil {
+		t.Error("unknown tool did not fail")
+	}
+}
+
+func TestLookupFailed(t *testing.T) {
+	tests := []struct {
+		name, text string
+		want       bool
+	}{
+		{name: "success", text: `{"Gender":"F","Code":"Success"}`},
+		{name: "payment required", text: `{"status":"payment_required","accepts":[]}`, want: true},
+		{name: "batch succeeded", text: `{"batch":true,"succeeded":2,"failed":0}`},
+		{name: "batch failed", text: `{"batch":true,"succeeded":1,"failed":1}`, want: true},
+		{name: "not json", text: "plain text"},
+	}
+	for _, tt := range tests {
+		if got := lookupFailed(tt.text); got != tt.want {
+			t.Errorf("%s: lookupFailed = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// A single call answered with x402 payment requirements exits 1, as the
+// same answer inside a batch does.
+func TestRunCallCommandPaymentRequired(t *testing.T) {
+	resetReloadable(t)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		w.Write([]byte(`{"x402Version":1,"accepts":[]}`))
+	}))
+	t.Cleanup(upstream.Close)
+	t.Setenv("INTERZOID_API_KEY", "")
+	t.Setenv("INTERZOID_API_BASE_URL", upstream.URL)
+	baseURL, timeout, transport := interzoidBaseURL, httpClient.Timeout, httpClient.Transport
+	t.Cleanup(func() {
+		interzoidBaseURL, httpClient.Timeout, httpClient.Transport = baseURL, timeout, transport
+	})
+
+	if status := runCallCommand([]string{"interzoid_gender", "--arg", "name=Maria"}); status != 1 {
+		t.Errorf("exit status = %d, want 1", status)
+	}
+}
+
+func TestWriteResultTable(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{
+			name: "single",
+			text: `{"Gender":"F","Code":"Success","Score":0.9}`,
+			want: "Code    Success\nGender  F\nScore   0.9\n",
+		},
+		{
+			name: "batch",
+			text: `{"batch":true,"succeeded":1,"failed":1,"results":[` +
+				`{"input":{"zip":"94105"},"result":{"City":"San Francisco"}},` +
+				`{"input":{"zip":"1"},"error":"invalid zip"}]}`,
+			want: "zip    City           error\n94105  San Francisco  \n1                     invalid zip\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := writeResultTable(&b, tt.text); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("table =\n%q\nwant\n%q", b.String(), tt.want)
+			}
+		})
+	}
+
+	if err := writeResultTable(&strings.Builder{}, "not json"); err == nil {
+		t.Error("non-JSON result written as a table")
+	}
+}
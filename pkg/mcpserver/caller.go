@@ -0,0 +1,107 @@
+package mcpserver
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+type contextKey int
+
+const (
+	remoteAddrKey  contextKey = iota
+	connHeaderKey             // headers of a connection-oriented transport
+	keyFuncKey                // KeyFunc set by NewServer
+	apiClientKey              // *interzoid.Client set by NewServer
+	serverStateKey            // *serverState of a server built by NewServer
+)
+
+// trustProxyHeaders controls whether X-Forwarded-For / X-Real-IP are used to
+// determine the client IP. Only enable it when the server sits behind a
+// reverse proxy (e.g. Nginx) that overwrites those headers.
+var trustProxyHeaders bool
+
+// httpContextFunc records the client IP of an incoming HTTP request in the
+// context so tool handlers can identify anonymous callers.
+func httpContextFunc(ctx context.Context, r *http.Request) context.Context {
+	return context.WithValue(ctx, remoteAddrKey, clientIP(r))
+}
+
+// localContextFunc is the context function for the Unix socket transport.
+// Its callers are local processes already admitted by the socket's file
+// permissions, so they are identified like stdio callers.
+func localContextFunc(ctx context.Context, r *http.Request) context.Context {
+	return ctx
+}
+
+// clientIP returns the IP address of the HTTP client, honoring proxy headers
+// only when trustProxyHeaders is set.
+func clientIP(r *http.Request) string {
+	if trustProxyHeaders {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			first, _, _ := strings.Cut(xff, ",")
+			return strings.TrimSpace(first)
+		}
+		if ip := r.Header.Get("X-Real-IP"); ip != "" {
+			return strings.TrimSpace(ip)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// callerID identifies who is making a tool call, using the following priority:
+//  1. The API key the caller supplied, or the key WithKeyFunc resolved for
+//     the call (hashed, so keys never appear in logs or limiter state). The
+//     server's own key (INTERZOID_API_KEY) is shared by every caller that
+//     sends none, so it never identifies one.
+//  2. The client IP for anonymous HTTP callers
+//  3. "local" for anonymous stdio callers
+func callerID(ctx context.Context, request mcp.CallToolRequest) string {
+	apiKey := headerAPIKey(request)
+	if keyFunc, ok := ctx.Value(keyFuncKey).(KeyFunc); ok {
+		apiKey = keyFunc(ctx, request)
+	}
+	if apiKey != "" {
+		return keyID(apiKey)
+	}
+	if ip, ok := ctx.Value(remoteAddrKey).(string); ok && ip != "" {
+		return "ip:" + ip
+	}
+	return "local"
+}
+
+// keyID is the caller ID of an API key.
+func keyID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return "key:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// isRemote reports whether the tool call arrived over an HTTP transport.
+func isRemote(ctx context.Context) bool {
+	_, ok := ctx.Value(remoteAddrKey).(string)
+	return ok
+}
+
+// defaultUsageTag is the chargeback tag for calls that carry none: usage.tag
+// in the configuration, or INTERZOID_USAGE_TAG. Like defaultAPIKey, it is set
+// only when the command loads its configuration.
+var defaultUsageTag string
+
+// usageTag returns the chargeback tag for a call, taken from the
+// X-Interzoid-Tag header (remote HTTP transport) or the configured default
+// (local stdio transport).
+func usageTag(request mcp.CallToolRequest) string {
+	if tag := request.Header.Get("X-Interzoid-Tag"); tag != "" {
+		return tag
+	}
+	return defaultUsageTag
+}
@@ -0,0 +1,63 @@
+package mcpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestCallerID(t *testing.T) {
+	t.Setenv("INTERZOID_API_KEY", "server-key")
+
+	remote := context.WithValue(context.Background(), remoteAddrKey, "203.0.113.7")
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		header http.Header
+		want   string
+	}{
+		{"client key", remote, http.Header{"Authorization": {"Bearer client-key"}}, keyID("client-key")},
+		{"client key without Bearer", context.Background(), http.Header{"Authorization": {"client-key"}}, keyID("client-key")},
+		{"anonymous remote caller", remote, nil, "ip:203.0.113.7"},
+		{"key function", context.WithValue(remote, keyFuncKey, KeyFunc(func(context.Context, mcp.CallToolRequest) string { return "tenant-key" })), http.Header{"Authorization": {"Bearer client-key"}}, keyID("tenant-key")},
+		// The server's own key is shared, so it does not identify the caller
+		{"local caller", context.Background(), nil, "local"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request mcp.CallToolRequest
+			request.Header = tt.header
+			if got := callerID(tt.ctx, request); got != tt.want {
+				t.Errorf("callerID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		trust  bool
+		header http.Header
+		want   string
+	}{
+		{false, nil, "192.0.2.1"},
+		{false, http.Header{"X-Forwarded-For": {"198.51.100.9"}}, "192.0.2.1"},
+		{true, http.Header{"X-Forwarded-For": {"198.51.100.9, 10.0.0.1"}}, "198.51.100.9"},
+		{true, http.Header{"X-Real-Ip": {"198.51.100.10"}}, "198.51.100.10"},
+	}
+	for _, tt := range tests {
+		trustProxyHeaders = tt.trust
+		r := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+		r.RemoteAddr = "192.0.2.1:4711"
+		for k, v := range tt.header {
+			r.Header[k] = v
+		}
+		if got := clientIP(r); got != tt.want {
+			t.Errorf("trust=%v %v: clientIP = %q, want %q", tt.trust, tt.header, got, tt.want)
+		}
+	}
+	trustProxyHeaders = false
+}
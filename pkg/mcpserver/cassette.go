@@ -0,0 +1,242 @@
+package mcpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// RECORD / REPLAY
+// ============================================================================
+//
+// With -record <file>, every upstream request and its response are saved to
+// a cassette file as they happen. With -replay <file>, responses are served
+// from the cassette and nothing is sent over the network, so tool behavior
+// can be reproduced exactly — in regression tests, or from traffic captured
+// at a customer site.
+//
+// API keys never reach a cassette: the x-api-key and Authorization headers
+// and key-like query parameters are replaced with "REDACTED".
+//
+// Requests are matched on method, path and query, ignoring the host so a
+// cassette recorded against one base URL replays against another. A request
+// recorded several times is replayed in the order recorded, repeating the
+// last response once they are used up.
+// ============================================================================
+
+const (
+	cassetteVersion = 1
+	redacted        = "REDACTED"
+)
+
+// scrubbedHeaders and scrubbedParams carry API keys.
+var (
+	scrubbedHeaders = []string{"X-Api-Key", "Authorization"}
+	scrubbedParams  = []string{"license", "apikey", "api_key", "key"}
+)
+
+// cassette is the file format: the recorded interactions, in order.
+type cassette struct {
+	Version      int           `json:"version"`
+	Interactions []interaction `json:"interactions"`
+}
+
+type interaction struct {
+	Request    recordedRequest  `json:"request"`
+	Response   recordedResponse `json:"response"`
+	RecordedAt time.Time        `json:"recordedAt"`
+}
+
+type recordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+}
+
+type recordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// cassetteTransport records or replays upstream HTTP traffic.
+type cassetteTransport struct {
+	path   string
+	replay bool
+	next   http.RoundTripper // used when recording
+
+	mu       sync.Mutex
+	tape     cassette
+	replayed map[string]int // per match key, how many interactions were served
+}
+
+// newCassetteTransport opens the cassette at path. Recording appends to an
+// existing cassette; replaying requires one.
+func newCassetteTransport(path string, replay bool, next http.RoundTripper) (*cassetteTransport, error) {
+	t := &cassetteTransport{
+		path:     path,
+		replay:   replay,
+		next:     next,
+		tape:     cassette{Version: cassetteVersion},
+		replayed: make(map[string]int),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !replay {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	if err := json.Unmarshal(data, &t.tape); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	if t.tape.Version != cassetteVersion {
+		return nil, fmt.Errorf("cassette %s has version %d, expected %d", path, t.tape.Version, cassetteVersion)
+	}
+	return t, nil
+}
+
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.replay {
+		return t.play(req)
+	}
+	return t.record(req)
+}
+
+// play serves the next recorded response for the request.
+func (t *cassetteTransport) play(req *http.Request) (*http.Response, error) {
+	key := matchKey(req.Method, req.URL)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var matches []*interaction
+	for i := range t.tape.Interactions {
+		in := &t.tape.Interactions[i]
+		if u, err := url.Parse(in.Request.URL); err == nil && matchKey(in.Request.Method, u) == key {
+			matches = append(matches, in)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no recorded response in %s for %s", t.path, key)
+	}
+
+	n := t.replayed[key]
+	t.replayed[key] = n + 1
+	if n >= len(matches) {
+		n = len(matches) - 1
+	}
+	rec := matches[n].Response
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
+
+// record sends the request and saves the exchange to the cassette.
+func (t *cassetteTransport) record(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		// Network failures are not recorded; there is no response to replay
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	in := interaction{
+		Request: recordedRequest{
+			Method: req.Method,
+			URL:    scrubURL(req.URL).String(),
+			Header: scrubHeader(req.Header),
+		},
+		Response: recordedResponse{
+			Status: resp.StatusCode,
+			Header: scrubHeader(resp.Header),
+			Body:   string(body),
+		},
+		RecordedAt: time.Now().UTC(),
+	}
+
+	// The upstream call has been made (and possibly charged for), so a
+	// cassette that cannot be written must not fail it. The interaction
+	// stays in memory and is written with the next one that saves.
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tape.Interactions = append(t.tape.Interactions, in)
+	if err := t.save(); err != nil {
+		log.Printf("Failed to record %s to cassette %s: %v\n", in.Request.URL, t.path, err)
+	}
+	return resp, nil
+}
+
+// save writes the cassette atomically. Callers must hold t.mu.
+func (t *cassetteTransport) save() error {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, t.tape); err != nil {
+		return err
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
+
+// matchKey identifies a request for replay: its method, path and scrubbed
+// query in canonical order.
+func matchKey(method string, u *url.URL) string {
+	scrubbed := scrubURL(u)
+	return method + " " + scrubbed.Path + "?" + scrubbed.Query().Encode()
+}
+
+// scrubURL returns a copy of u with key-like query parameters redacted.
+func scrubURL(u *url.URL) *url.URL {
+	out := *u
+	q := out.Query()
+	for name := range q {
+		for _, p := range scrubbedParams {
+			if strings.EqualFold(name, p) {
+				q.Set(name, redacted)
+			}
+		}
+	}
+	out.RawQuery = q.Encode()
+	return &out
+}
+
+// scrubHeader returns a copy of h with key-bearing headers redacted and
+// cookies dropped.
+func scrubHeader(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range scrubbedHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	out.Del("Cookie")
+	out.Del("Set-Cookie")
+	return out
+}
@@ -0,0 +1,138 @@
+package mcpserver
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// getThrough makes a GET request for url through transport and returns the
+// response body.
+func getThrough(t *testing.T, transport http.RoundTripper, url string, header http.Header) string {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header != nil {
+		req.Header = header
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestCassetteRecordScrubsKeys(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "secret-session"})
+		io.WriteString(w, `{"Code":"Success"}`)
+	}))
+	defer upstream.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	transport, err := newCassetteTransport(path, false, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := http.Header{"X-Api-Key": {"secret-key"}, "Authorization": {"Bearer secret-bearer"}}
+	getThrough(t, transport, upstream.URL+"/getorgstandard?org=IBM&license=secret-license", header)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"secret-key", "secret-bearer", "secret-license", "secret-session"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains %q:\n%s", secret, data)
+		}
+	}
+
+	// The scrubbed request still replays, from any host
+	replay, err := newCassetteTransport(path, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := getThrough(t, replay, "https://api.interzoid.com/getorgstandard?license=other&org=IBM", nil); got != `{"Code":"Success"}` {
+		t.Errorf("replayed body = %q", got)
+	}
+}
+
+func TestCassetteReplayOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"in recorded order", []string{"first", "second"}},
+		{"repeating the last response", []string{"first", "second", "second"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cassette.json")
+			err := os.WriteFile(path, []byte(`{"version": 1, "interactions": [
+				{"request": {"method": "GET", "url": "http://x/getgender?name=Maria"}, "response": {"status": 200, "body": "first"}},
+				{"request": {"method": "GET", "url": "http://x/getgender?name=Maria"}, "response": {"status": 200, "body": "second"}}
+			]}`), 0o600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			transport, err := newCassetteTransport(path, true, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.want {
+				if got := getThrough(t, transport, "http://y/getgender?name=Maria", nil); got != want {
+					t.Errorf("response %d = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+// Replay never falls back to the network for a request it has no response for.
+func TestCassetteReplayUnrecorded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	err := os.WriteFile(path, []byte(`{"version": 1, "interactions": [
+		{"request": {"method": "GET", "url": "http://x/getgender?name=Maria"}, "response": {"status": 200, "body": "{}"}}
+	]}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport, err := newCassetteTransport(path, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://x/getgender?name=Ana", nil)
+	if _, err := transport.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("err = %v, want no recorded response", err)
+	}
+}
+
+// A cassette that cannot be written must not fail an upstream call that has
+// already been made.
+func TestCassetteRecordSaveFailure(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"Code":"Success"}`)
+	}))
+	defer upstream.Close()
+
+	path := filepath.Join(t.TempDir(), "missing", "cassette.json")
+	transport, err := newCassetteTransport(path, false, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := getThrough(t, transport, upstream.URL+"/getgender?name=Maria", nil); got != `{"Code":"Success"}` {
+		t.Errorf("body = %q, want the upstream response", got)
+	}
+}
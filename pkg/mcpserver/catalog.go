@@ -0,0 +1,199 @@
+package mcpserver
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Tool categories, matching the sections of registerAllTools.
+const (
+	catMatching        = "Data Matching"
+	catEnrichment      = "Data Enrichment"
+	catStandardization = "Data Standardization"
+	catEnhancement     = "Data Enhancement"
+	catUtility         = "Utility"
+	catServer          = "Server"
+)
+
+// categoryDescriptions summarizes each category of API tools.
+var categoryDescriptions = map[string]string{
+	catMatching:        "Similarity key generation & scoring",
+	catEnrichment:      "AI-powered intelligence",
+	catStandardization: "Canonical form normalization",
+	catEnhancement:     "Classification & analysis",
+	catUtility:         "Weather, currency, ZIP lookup",
+}
+
+// apiCategories lists the categories of API tools in registration order.
+var apiCategories = []string{catMatching, catEnrichment, catStandardization, catEnhancement, catUtility}
+
+// catalogEntry describes one Interzoid API tool: what it calls, what it
+// takes, and what it costs.
+type catalogEntry struct {
+	Name            string         `json:"name"`
+	Title           string         `json:"title"`
+	Description     string         `json:"description"`
+	Category        string         `json:"category"`
+	Endpoint        string         `json:"endpoint"`
+	PriceTier       string         `json:"priceTier"`
+	PriceUSD        float64        `json:"priceUSD"`
+	PriceAtomicUSDC int64          `json:"priceAtomicUSDC"`
+	Credits         int64          `json:"credits"`
+	Parameters      []catalogParam `json:"parameters"`
+}
+
+// catalogParam describes one tool parameter and the API query parameter it
+// is sent as.
+type catalogParam struct {
+	Name        string   `json:"name"`
+	APIName     string   `json:"apiName"`
+	Required    bool     `json:"required"`
+	Description string   `json:"description"`
+	Enum        []string `json:"enum,omitempty"`
+}
+
+// toolCatalog holds an entry for every API tool a server offers. Each
+// server has its own, filled by registerAllTools and kept in step with the
+// server's tool list by filterTools and syncTools.
+type toolCatalog struct {
+	mu      sync.RWMutex
+	entries map[string]catalogEntry
+}
+
+func newToolCatalog() *toolCatalog {
+	return &toolCatalog{entries: make(map[string]catalogEntry)}
+}
+
+// add records a tool in the catalog, replacing any previous entry with the
+// same name.
+func (c *toolCatalog) add(category string, tool mcp.Tool, endpoint string, requiredParams, optionalParams []paramMapping) {
+	tier := tierForEndpoint(endpoint)
+	entry := catalogEntry{
+		Name:            tool.Name,
+		Title:           tool.Annotations.Title,
+		Description:     tool.Description,
+		Category:        category,
+		Endpoint:        endpoint,
+		PriceTier:       tier.name,
+		PriceUSD:        tier.usd(),
+		PriceAtomicUSDC: tier.atomicUSDC,
+		Credits:         tier.credits,
+	}
+
+	for _, group := range []struct {
+		params   []paramMapping
+		required bool
+	}{{requiredParams, true}, {optionalParams, false}} {
+		for _, p := range group.params {
+			param := catalogParam{
+				Name:     p.toolName,
+				APIName:  p.apiName,
+				Required: group.required,
+			}
+			if prop, ok := tool.InputSchema.Properties[p.toolName].(map[string]any); ok {
+				param.Description, _ = prop["description"].(string)
+				param.Enum, _ = prop["enum"].([]string)
+			}
+			entry.Parameters = append(entry.Parameters, param)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[tool.Name] = entry
+}
+
+// remove drops the entries of the named tools.
+func (c *toolCatalog) remove(names ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, name := range names {
+		delete(c.entries, name)
+	}
+}
+
+// annotateTool sets the behavior hints and machine-readable cost metadata
+// that clients use to decide which calls to auto-approve. Every tool here is
+// a read-only lookup, but only free ones are idempotent: repeating a priced
+// call is charged again. openWorld marks tools that reach out to the
+// Interzoid API rather than only reading local server state.
+func annotateTool(tool mcp.Tool, category string, tier priceTier, openWorld bool) mcp.Tool {
+	tool.Annotations.ReadOnlyHint = mcp.ToBoolPtr(true)
+	tool.Annotations.DestructiveHint = mcp.ToBoolPtr(false)
+	tool.Annotations.IdempotentHint = mcp.ToBoolPtr(tier.atomicUSDC == 0)
+	tool.Annotations.OpenWorldHint = mcp.ToBoolPtr(openWorld)
+
+	tool.Meta = mcp.NewMetaFromMap(map[string]any{
+		"com.interzoid/category": category,
+		"com.interzoid/price":    priceMetadata(tier),
+	})
+	return tool
+}
+
+// priceMetadata describes a price tier for machine-readable metadata.
+func priceMetadata(tier priceTier) map[string]any {
+	return map[string]any{
+		"tier":            tier.name,
+		"priceAtomicUSDC": tier.atomicUSDC,
+		"priceUSD":        tier.usd(),
+		"credits":         tier.credits,
+		"currency":        "USDC",
+		"network":         x402Network,
+		"asset":           usdcAsset,
+	}
+}
+
+// toolTier returns the price tier in a tool's metadata (see annotateTool).
+// Tools without one are free.
+func toolTier(tool mcp.Tool) priceTier {
+	if tool.Meta == nil {
+		return freeTier
+	}
+	price, _ := tool.Meta.AdditionalFields["com.interzoid/price"].(map[string]any)
+	for _, tier := range []priceTier{standardTier, premiumTier} {
+		if price["tier"] == tier.name {
+			return tier
+		}
+	}
+	return freeTier
+}
+
+// copyTo records the entries of the given tools in dst. Tools without an
+// entry, such as the server's own tools, are skipped.
+func (c *toolCatalog) copyTo(dst *toolCatalog, tools []server.ServerTool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+	for _, tool := range tools {
+		if e, ok := c.entries[tool.Tool.Name]; ok {
+			dst.entries[tool.Tool.Name] = e
+		}
+	}
+}
+
+// list returns every catalog entry sorted by category order and then by
+// name.
+func (c *toolCatalog) list() []catalogEntry {
+	c.mu.RLock()
+	entries := make([]catalogEntry, 0, len(c.entries))
+	for _, e := range c.entries {
+		entries = append(entries, e)
+	}
+	c.mu.RUnlock()
+
+	order := make(map[string]int, len(apiCategories))
+	for i, c := range apiCategories {
+		order[c] = i
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Category != entries[j].Category {
+			return order[entries[i].Category] < order[entries[j].Category]
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
@@ -0,0 +1,115 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+
+	"github.com/interzoid/interzoid-mcp-server/pkg/interzoid"
+)
+
+const (
+	defaultBaseURL = interzoid.DefaultBaseURL
+	httpTimeout    = interzoid.DefaultTimeout
+)
+
+// interzoidBaseURL is where API calls are sent. It is set from the
+// configuration (api.base_url).
+var interzoidBaseURL = defaultBaseURL
+
+var httpClient = &http.Client{Timeout: httpTimeout}
+
+// upstreamClient is the Interzoid client tool calls are made with, before
+// the caller's key is applied. It carries the response cache and retry
+// settings, and is replaced (emptying the cache) when a configuration reload
+// changes the cache settings. It holds nil until the configuration is
+// applied, and calls are then made without either.
+var upstreamClient atomic.Pointer[interzoid.Client]
+
+// callInterzoidAPI makes an HTTP GET request to the Interzoid API endpoint
+// through the Interzoid client (pkg/interzoid).
+//
+// Authentication priority (first match wins):
+//   1. API key passed in from the connecting client's Authorization header
+//      (remote HTTP transport — user provides their own key)
+//   2. INTERZOID_API_KEY environment variable (local stdio transport)
+//   3. No key — triggers x402 payment flow
+//
+// When an API key is available, it is sent as the "x-api-key" header to
+// the Interzoid API (matching the existing API authentication convention).
+// When no key is available, the request is sent without authentication,
+// triggering a 402 Payment Required response for x402 payment negotiation.
+//
+// The request is abandoned when ctx is canceled, for example when the MCP
+// client that made the tool call disconnects.
+func callInterzoidAPI(ctx context.Context, apiKey string, endpoint string, params map[string]string) (map[string]interface{}, error) {
+	query := queryValues(params)
+
+	// Fail fast while the endpoint's circuit breaker is open rather than
+	// waiting out the full httpTimeout on an endpoint that is known to be down
+	done, err := upstreamBreakers.Load().allow(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := apiClient(ctx, apiKey).Call(ctx, endpoint, query)
+
+	// Only failures to get an answer and server-side failures count against
+	// the breaker; 4xx responses (including 402 for x402) mean the endpoint
+	// itself is healthy
+	var apiErr *interzoid.Error
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == 0 || apiErr.StatusCode >= http.StatusInternalServerError) {
+		done(err)
+	} else {
+		done(nil)
+	}
+
+	// In x402 mode, a 402 is expected — return the payment requirements
+	// so the calling agent/client can handle the payment flow. With an API
+	// key, a 402 means the account is out of credits
+	if apiErr != nil && apiErr.Code == interzoid.CodePaymentRequired && apiErr.PaymentRequirements != nil {
+		return map[string]interface{}{
+			"status":              "payment_required",
+			"x402":                true,
+			"paymentRequirements": apiErr.PaymentRequirements,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// queryValues returns the query string of an upstream call with params.
+func queryValues(params map[string]string) url.Values {
+	query := make(url.Values, len(params))
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	return query
+}
+
+// apiClient returns the Interzoid client for a call made with apiKey: the
+// client given to NewServer with WithClient, or else upstreamClient, which
+// follows the current configuration (api.base_url, api.timeout, retries, the
+// response cache and any record/replay cassette). An injected client keeps
+// its own key when the call has none.
+func apiClient(ctx context.Context, apiKey string) *interzoid.Client {
+	if client, ok := ctx.Value(apiClientKey).(*interzoid.Client); ok {
+		if apiKey == "" {
+			return client
+		}
+		return client.WithKey(apiKey)
+	}
+	if client := upstreamClient.Load(); client != nil {
+		return client.WithKey(apiKey)
+	}
+	return interzoid.NewClient(
+		interzoid.WithBaseURL(interzoidBaseURL),
+		interzoid.WithAPIKey(apiKey),
+		interzoid.WithHTTPClient(httpClient),
+	)
+}
@@ -0,0 +1,236 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============================================================================
+// ARGUMENT COMPLETIONS
+// ============================================================================
+//
+// MCP's completion/complete request only references prompts and resource
+// templates, not tools. Known parameter domains are therefore exposed three
+// ways:
+//
+//   - Prompt arguments that feed a tool parameter complete from that
+//     parameter's values (e.g. the algorithm of deduplicate_company_list).
+//   - The interzoid://reference/{tool}/{parameter} resource template lists a
+//     tool parameter's values, and its {tool} and {parameter} arguments
+//     complete to the tools and parameters that have a known domain.
+//   - The interzoid://reference/{tool}/{parameter}/{value} template checks a
+//     single value, and its {value} argument completes from the parameter's
+//     domain: language names, currency codes, country names or algorithms.
+// ============================================================================
+
+const (
+	referenceTemplateURI      = "interzoid://reference/{tool}/{parameter}"
+	referenceValueTemplateURI = "interzoid://reference/{tool}/{parameter}/{value}"
+)
+
+// maxCompletionValues is the protocol limit on values per completion.
+const maxCompletionValues = 100
+
+// currencyCodes returns the bundled ISO 4217 codes in sorted order.
+func currencyCodes() []string {
+	codes := make([]string, 0, len(iso4217Currencies))
+	for code := range iso4217Currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// parameterDomains returns the known values of each enumerable tool
+// parameter, keyed by tool name and then parameter name.
+func parameterDomains() map[string]map[string][]string {
+	currencies := currencyCodes()
+	domains := map[string]map[string][]string{
+		"interzoid_translate_to_any": {"to": worldLanguages},
+		"interzoid_currency_rate":    {"from": currencies, "to": currencies},
+		"interzoid_country_standard": {"country": countryNames},
+		"interzoid_country_info":     {"country": countryNames},
+	}
+	for tool, variants := range algorithmVariants {
+		if domains[tool] == nil {
+			domains[tool] = make(map[string][]string)
+		}
+		domains[tool]["algorithm"] = variants
+	}
+	return domains
+}
+
+// promptArgumentParams maps prompt arguments to the tool parameter whose
+// domain they share.
+var promptArgumentParams = map[string]map[string][2]string{
+	"deduplicate_company_list": {"algorithm": {"interzoid_company_match_advanced", "algorithm"}},
+}
+
+// completeValues returns the values that start with prefix (case-insensitive),
+// falling back to values that contain it, capped at the protocol limit.
+func completeValues(values []string, prefix string) *mcp.Completion {
+	prefix = strings.ToLower(prefix)
+	var starts, contains []string
+	for _, v := range values {
+		lv := strings.ToLower(v)
+		switch {
+		case strings.HasPrefix(lv, prefix):
+			starts = append(starts, v)
+		case strings.Contains(lv, prefix):
+			contains = append(contains, v)
+		}
+	}
+
+	matches := append(starts, contains...)
+	completion := &mcp.Completion{Values: matches, Total: len(matches)}
+	if len(matches) > maxCompletionValues {
+		completion.Values = matches[:maxCompletionValues]
+		completion.HasMore = true
+	}
+	if completion.Values == nil {
+		completion.Values = []string{}
+	}
+	return completion
+}
+
+// sortedKeys returns the keys of m in sorted order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// completionProvider answers completion/complete for prompts and for the
+// reference resource template.
+type completionProvider struct{}
+
+func (completionProvider) CompletePromptArgument(ctx context.Context, promptName string, argument mcp.CompleteArgument, cc mcp.CompleteContext) (*mcp.Completion, error) {
+	target, ok := promptArgumentParams[promptName][argument.Name]
+	if !ok {
+		return &mcp.Completion{Values: []string{}}, nil
+	}
+	return completeValues(parameterDomains()[target[0]][target[1]], argument.Value), nil
+}
+
+func (completionProvider) CompleteResourceArgument(ctx context.Context, uri string, argument mcp.CompleteArgument, cc mcp.CompleteContext) (*mcp.Completion, error) {
+	if uri != referenceTemplateURI && uri != referenceValueTemplateURI {
+		return &mcp.Completion{Values: []string{}}, nil
+	}
+
+	domains := parameterDomains()
+	switch argument.Name {
+	case "tool":
+		return completeValues(sortedKeys(domains), argument.Value), nil
+	case "parameter":
+		return completeValues(sortedKeys(domains[cc.Arguments["tool"]]), argument.Value), nil
+	case "value":
+		return completeValues(domains[cc.Arguments["tool"]][cc.Arguments["parameter"]], argument.Value), nil
+	default:
+		return &mcp.Completion{Values: []string{}}, nil
+	}
+}
+
+// registerReferenceTemplates registers the resource templates that list the
+// known values of a tool parameter and check a single value.
+func registerReferenceTemplates(s *server.MCPServer) {
+	s.AddResourceTemplate(
+		mcp.NewResourceTemplate(referenceTemplateURI, "Tool parameter values",
+			mcp.WithTemplateDescription("Known values for an enumerable tool parameter, such as the language names accepted by interzoid_translate_to_any, ISO 4217 codes for interzoid_currency_rate, or the algorithm variants of a matching tool."),
+			mcp.WithTemplateMIMEType("application/json"),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			tool := templateArgument(request, "tool")
+			param := templateArgument(request, "parameter")
+
+			values, ok := parameterDomains()[tool][param]
+			if !ok {
+				return nil, fmt.Errorf("no reference values for %s parameter %q", tool, param)
+			}
+
+			jsonBytes, err := json.MarshalIndent(map[string]interface{}{
+				"tool":      tool,
+				"parameter": param,
+				"values":    values,
+			}, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("failed to format resource: %w", err)
+			}
+
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{
+					URI:      request.Params.URI,
+					MIMEType: "application/json",
+					Text:     string(jsonBytes),
+				},
+			}, nil
+		},
+	)
+
+	s.AddResourceTemplate(
+		mcp.NewResourceTemplate(referenceValueTemplateURI, "Tool parameter value check",
+			mcp.WithTemplateDescription("Whether a value is one of the known values of an enumerable tool parameter, with its canonical spelling or close matches. The {value} argument completes to the parameter's known values."),
+			mcp.WithTemplateMIMEType("application/json"),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			tool := templateArgument(request, "tool")
+			param := templateArgument(request, "parameter")
+			value := templateArgument(request, "value")
+
+			values, ok := parameterDomains()[tool][param]
+			if !ok {
+				return nil, fmt.Errorf("no reference values for %s parameter %q", tool, param)
+			}
+
+			check := map[string]interface{}{
+				"tool":      tool,
+				"parameter": param,
+				"value":     value,
+				"known":     false,
+			}
+			for _, v := range values {
+				if strings.EqualFold(v, value) {
+					check["known"] = true
+					check["canonical"] = v
+				}
+			}
+			if check["known"] == false {
+				check["suggestions"] = completeValues(values, value).Values
+			}
+
+			jsonBytes, err := json.MarshalIndent(check, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("failed to format resource: %w", err)
+			}
+
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{
+					URI:      request.Params.URI,
+					MIMEType: "application/json",
+					Text:     string(jsonBytes),
+				},
+			}, nil
+		},
+	)
+}
+
+// templateArgument returns a URI template variable from a resource read.
+func templateArgument(request mcp.ReadResourceRequest, name string) string {
+	switch v := request.Params.Arguments[name].(type) {
+	case string:
+		return v
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
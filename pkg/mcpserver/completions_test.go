@@ -0,0 +1,174 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// newCompletionServer returns a server with the prompts, reference
+// templates and completion providers registered, as main sets them up.
+func newCompletionServer() *server.MCPServer {
+	s := server.NewMCPServer(serverName, serverVersion,
+		server.WithResourceCapabilities(false, false),
+		server.WithPromptCapabilities(false),
+		server.WithCompletions(),
+		server.WithPromptCompletionProvider(completionProvider{}),
+		server.WithResourceCompletionProvider(completionProvider{}),
+	)
+	registerAllTools(s)
+	registerReferenceTemplates(s)
+	registerPrompts(s)
+	return s
+}
+
+// handle sends a JSON-RPC request to s and returns its result as JSON, or
+// the error message.
+func handle(t *testing.T, s *server.MCPServer, method string, params interface{}) (string, string) {
+	t.Helper()
+	message, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": method, "params": params})
+	switch response := s.HandleMessage(context.Background(), message).(type) {
+	case mcp.JSONRPCResponse:
+		result, _ := json.Marshal(response.Result)
+		return string(result), ""
+	case mcp.JSONRPCError:
+		return "", response.Error.Message
+	default:
+		t.Fatalf("unexpected response %#v", response)
+		return "", ""
+	}
+}
+
+func TestCompletions(t *testing.T) {
+	s := newCompletionServer()
+	tests := []struct {
+		name    string
+		ref     map[string]string
+		arg     string
+		value   string
+		context map[string]string
+		want    []string
+	}{
+		{
+			name:    "language",
+			ref:     map[string]string{"type": "ref/resource", "uri": referenceValueTemplateURI},
+			arg:     "value",
+			value:   "jap",
+			context: map[string]string{"tool": "interzoid_translate_to_any", "parameter": "to"},
+			want:    []string{"Japanese"},
+		},
+		{
+			name:    "currency",
+			ref:     map[string]string{"type": "ref/resource", "uri": referenceValueTemplateURI},
+			arg:     "value",
+			value:   "eu",
+			context: map[string]string{"tool": "interzoid_currency_rate", "parameter": "from"},
+			want:    []string{"EUR"},
+		},
+		{
+			name:    "country by substring",
+			ref:     map[string]string{"type": "ref/resource", "uri": referenceValueTemplateURI},
+			arg:     "value",
+			value:   "zealand",
+			context: map[string]string{"tool": "interzoid_country_standard", "parameter": "country"},
+			want:    []string{"New Zealand"},
+		},
+		{
+			name:    "parameter",
+			ref:     map[string]string{"type": "ref/resource", "uri": referenceTemplateURI},
+			arg:     "parameter",
+			context: map[string]string{"tool": "interzoid_currency_rate"},
+			want:    []string{"from", "to"},
+		},
+		{
+			name:  "prompt algorithm",
+			ref:   map[string]string{"type": "ref/prompt", "name": "deduplicate_company_list"},
+			arg:   "algorithm",
+			value: "ai-",
+			want:  []string{"ai-medium", "ai-plus", "ai-deep"},
+		},
+		{
+			name:  "unknown prompt argument",
+			ref:   map[string]string{"type": "ref/prompt", "name": "deduplicate_company_list"},
+			arg:   "companies",
+			value: "I",
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := map[string]interface{}{
+				"ref":      tt.ref,
+				"argument": map[string]string{"name": tt.arg, "value": tt.value},
+			}
+			if tt.context != nil {
+				params["context"] = map[string]interface{}{"arguments": tt.context}
+			}
+			text, errMsg := handle(t, s, "completion/complete", params)
+			if errMsg != "" {
+				t.Fatal(errMsg)
+			}
+			var result mcp.CompleteResult
+			if err := json.Unmarshal([]byte(text), &result); err != nil {
+				t.Fatal(err)
+			}
+			got, _ := json.Marshal(result.Completion.Values)
+			want, _ := json.Marshal(tt.want)
+			if string(got) != string(want) {
+				t.Errorf("values = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestCompleteValuesLimit(t *testing.T) {
+	completion := completeValues(currencyCodes(), "")
+	if len(completion.Values) != maxCompletionValues || !completion.HasMore || completion.Total != len(iso4217Currencies) {
+		t.Errorf("completion = %d values, hasMore %v, total %d", len(completion.Values), completion.HasMore, completion.Total)
+	}
+}
+
+func TestReferenceTemplates(t *testing.T) {
+	s := newCompletionServer()
+	tests := []struct {
+		uri     string
+		want    []string
+		wantErr string
+	}{
+		{uri: "interzoid://reference/interzoid_translate_to_any/to", want: []string{`"Japanese"`, `"Zulu"`}},
+		{uri: "interzoid://reference/interzoid_currency_rate/to/usd", want: []string{`"known":true`, `"canonical":"USD"`}},
+		{uri: "interzoid://reference/interzoid_country_info/country/Germny", want: []string{`"known":false`, `"suggestions":[]`}},
+		{uri: "interzoid://reference/interzoid_country_info/country/land", want: []string{`"known":false`, `"Finland"`}},
+		{uri: "interzoid://reference/interzoid_gender/name", wantErr: "no reference values"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.uri, func(t *testing.T) {
+			text, errMsg := handle(t, s, "resources/read", map[string]string{"uri": tt.uri})
+			if tt.wantErr != "" {
+				if !strings.Contains(errMsg, tt.wantErr) {
+					t.Errorf("error = %q, want %q", errMsg, tt.wantErr)
+				}
+				return
+			}
+			if errMsg != "" {
+				t.Fatal(errMsg)
+			}
+			var result struct {
+				Contents []mcp.TextResourceContents `json:"contents"`
+			}
+			if err := json.Unmarshal([]byte(text), &result); err != nil {
+				t.Fatal(err)
+			}
+			contents := strings.Join(strings.Fields(result.Contents[0].Text), "")
+			for _, want := range tt.want {
+				if !strings.Contains(contents, want) {
+					t.Errorf("contents do not contain %s:\n%s", want, contents)
+				}
+			}
+		})
+	}
+}
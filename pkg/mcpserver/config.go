@@ -0,0 +1,634 @@
+package mcpserver
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/interzoid/interzoid-mcp-server/pkg/interzoid"
+	"github.com/mark3labs/mcp-go/server"
+	"gopkg.in/yaml.v3"
+)
+
+// ============================================================================
+// CONFIGURATION
+// ============================================================================
+//
+// Settings are merged in this order, later sources winning:
+//
+//   1. built-in defaults (defaultConfig)
+//   2. a YAML file named by -config or INTERZOID_CONFIG
+//   3. environment variables INTERZOID_<SECTION>_<KEY>, named after the YAML
+//      keys: api.key is INTERZOID_API_KEY, rate_limit.per_minute is
+//      INTERZOID_RATE_LIMIT_PER_MINUTE
+//   4. command-line flags
+//
+// Unknown keys in the file are errors. `interzoid-mcp-server config check`
+// prints the merged file and environment settings with secrets masked.
+// ============================================================================
+
+const configEnvPrefix = "INTERZOID_"
+
+// config is the server configuration. Fields tagged secret:"true" are masked
+// when printed.
+type config struct {
+	path string // the file it was loaded from, if any
+
+	API       apiSettings       `yaml:"api"`
+	Server    serverSettings    `yaml:"server"`
+	Sessions  sessionSettings   `yaml:"sessions"`
+	RateLimit rateLimitSettings `yaml:"rate_limit"`
+	Breaker   breakerSettings   `yaml:"breaker"`
+	Credits   creditSettings    `yaml:"credits"`
+	Budgets   budgetSettings    `yaml:"budgets"`
+	Cache     cacheSettings     `yaml:"cache"`
+	Usage     usageSettings     `yaml:"usage"`
+	Tools     toolFilter        `yaml:"tools"`
+	Logging   loggingSettings   `yaml:"logging"`
+}
+
+type apiSettings struct {
+	BaseURL      string        `yaml:"base_url"`
+	Key          string        `yaml:"key" secret:"true"`
+	Timeout      time.Duration `yaml:"timeout"`
+	Retries      int           `yaml:"retries"`
+	RetryBackoff time.Duration `yaml:"retry_backoff"`
+	Record       string        `yaml:"record"`
+	Replay       string        `yaml:"replay"`
+}
+
+type serverSettings struct {
+	Transport  string `yaml:"transport"`
+	Port       string `yaml:"port"`
+	Socket     string `yaml:"socket"`
+	SocketMode string `yaml:"socket_mode"`
+	TrustProxy bool   `yaml:"trust_proxy"`
+	Metrics    bool   `yaml:"metrics"`
+	REST       bool   `yaml:"rest"`
+	// WatchConfig reloads the configuration when its file changes
+	WatchConfig bool `yaml:"watch_config"`
+}
+
+type sessionSettings struct {
+	Stateless       bool          `yaml:"stateless"`
+	Store           string        `yaml:"store"`
+	StreamRetention time.Duration `yaml:"stream_retention"`
+}
+
+type rateLimitSettings struct {
+	PerMinute float64 `yaml:"per_minute"`
+	Burst     int     `yaml:"burst"`
+	PerTool   bool    `yaml:"per_tool"`
+}
+
+type breakerSettings struct {
+	FailureRatio   float64       `yaml:"failure_ratio"`
+	MinRequests    int           `yaml:"min_requests"`
+	Window         time.Duration `yaml:"window"`
+	OpenDuration   time.Duration `yaml:"open_duration"`
+	HalfOpenProbes int           `yaml:"half_open_probes"`
+}
+
+type creditSettings struct {
+	Guard      bool `yaml:"guard"`
+	FailClosed bool `yaml:"fail_closed"`
+}
+
+// budgetSettings caps spending, in USD at x402 prices.
+type budgetSettings struct {
+	Session float64 `yaml:"session"`
+}
+
+// cacheSettings configures the upstream response cache. A zero TTL disables
+// it.
+type cacheSettings struct {
+	TTL        time.Duration `yaml:"ttl"`
+	MaxEntries int           `yaml:"max_entries"`
+}
+
+type usageSettings struct {
+	Ledger string `yaml:"ledger"`
+	Tag    string `yaml:"tag"`
+}
+
+// toolFilter selects the tools a server offers by name, with path.Match
+// patterns such as "interzoid_*_match*". An empty include list includes
+// every tool; exclude wins over include.
+type toolFilter struct {
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+}
+
+type loggingSettings struct {
+	File string `yaml:"file"`
+}
+
+// defaultConfig returns the built-in defaults.
+func defaultConfig() *config {
+	return &config{
+		API: apiSettings{
+			BaseURL:      defaultBaseURL,
+			Timeout:      httpTimeout,
+			RetryBackoff: 500 * time.Millisecond,
+		},
+		Server: serverSettings{
+			Transport:  "stdio",
+			Port:       "8080",
+			Socket:     filepath.Join(os.TempDir(), "interzoid-mcp.sock"),
+			SocketMode: "0660",
+		},
+		Sessions: sessionSettings{StreamRetention: 10 * time.Minute},
+		RateLimit: rateLimitSettings{
+			Burst: 10,
+		},
+		Breaker: breakerSettings{
+			FailureRatio:   0.5,
+			MinRequests:    10,
+			Window:         time.Minute,
+			OpenDuration:   30 * time.Second,
+			HalfOpenProbes: 1,
+		},
+		Cache: cacheSettings{MaxEntries: 10000},
+	}
+}
+
+// loadConfig merges the defaults, the file at path (if any) and the
+// environment.
+func loadConfig(path string) (*config, error) {
+	cfg := defaultConfig()
+	cfg.path = path
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open config: %w", err)
+		}
+		defer f.Close()
+
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("invalid config %s: %w", path, err)
+		}
+	}
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadConfigFlag registers the -config flag on fs and loads the
+// configuration it names. args are scanned for the flag ahead of parsing, so
+// that the other flags can take their defaults from the configuration.
+func loadConfigFlag(fs *flag.FlagSet, args []string) (*config, error) {
+	path := os.Getenv(configEnvPrefix + "CONFIG")
+	for i, arg := range args {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || name != "config" {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			value = args[i+1]
+		}
+		path = value
+	}
+	fs.String("config", path, "YAML configuration file (also INTERZOID_CONFIG)")
+	return loadConfig(path)
+}
+
+// addCallFlags registers the flags that affect how tool calls are made,
+// shared by the server and the call and pipe subcommands. The flags write
+// into cfg, whose values are their defaults.
+func addCallFlags(fs *flag.FlagSet, cfg *config) {
+	fs.Float64Var(&cfg.RateLimit.PerMinute, "rate-limit", cfg.RateLimit.PerMinute, "Max tool calls per minute per caller (0 disables rate limiting)")
+	fs.IntVar(&cfg.RateLimit.Burst, "rate-burst", cfg.RateLimit.Burst, "Burst size for the per-caller rate limit")
+	fs.BoolVar(&cfg.RateLimit.PerTool, "rate-limit-per-tool", cfg.RateLimit.PerTool, "Apply the rate limit separately to each tool")
+	fs.Float64Var(&cfg.Breaker.FailureRatio, "breaker-failure-ratio", cfg.Breaker.FailureRatio, "Failure ratio that opens an endpoint's circuit breaker (0 disables)")
+	fs.IntVar(&cfg.Breaker.MinRequests, "breaker-min-requests", cfg.Breaker.MinRequests, "Calls in a window before the breaker failure ratio is evaluated")
+	fs.DurationVar(&cfg.Breaker.Window, "breaker-window", cfg.Breaker.Window, "Measurement window for the circuit breaker failure ratio")
+	fs.DurationVar(&cfg.Breaker.OpenDuration, "breaker-open-duration", cfg.Breaker.OpenDuration, "How long an open circuit fails fast before probing")
+	fs.IntVar(&cfg.Breaker.HalfOpenProbes, "breaker-half-open-probes", cfg.Breaker.HalfOpenProbes, "Concurrent probe calls allowed while a circuit is half-open")
+	fs.StringVar(&cfg.Usage.Ledger, "ledger", cfg.Usage.Ledger, "Record upstream calls in the SQLite usage ledger at this path (default off)")
+	fs.BoolVar(&cfg.Credits.Guard, "credit-guard", cfg.Credits.Guard, "Refuse calls whose estimated credit use exceeds the remaining balance")
+	fs.BoolVar(&cfg.Credits.FailClosed, "credit-guard-fail-closed", cfg.Credits.FailClosed, "Have the credit guard refuse calls when the balance cannot be fetched")
+	fs.IntVar(&cfg.API.Retries, "retries", cfg.API.Retries, "Times a failed upstream call that may succeed later (429, 5xx, timeout) is retried")
+	fs.DurationVar(&cfg.API.RetryBackoff, "retry-backoff", cfg.API.RetryBackoff, "Wait before the first retry, doubling on each one")
+	fs.StringVar(&cfg.API.Record, "record", cfg.API.Record, "Record upstream requests and responses to this cassette file")
+	fs.StringVar(&cfg.API.Replay, "replay", cfg.API.Replay, "Serve upstream responses from this cassette file instead of the network")
+	fs.DurationVar(&cfg.Cache.TTL, "cache-ttl", cfg.Cache.TTL, "How long successful upstream results are reused for identical calls (0 disables the cache)")
+	fs.IntVar(&cfg.Cache.MaxEntries, "cache-max-entries", cfg.Cache.MaxEntries, "Most results the response cache holds")
+}
+
+// addBudgetFlag registers the session budget flag, shared by the server and
+// the pipe subcommand, whose run is one session.
+func addBudgetFlag(fs *flag.FlagSet, cfg *config) {
+	fs.Float64Var(&cfg.Budgets.Session, "session-budget", cfg.Budgets.Session, "Most a session may spend on upstream calls, in USD at x402 prices (0 disables)")
+}
+
+// validate reports every invalid setting.
+func (c *config) validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	u, err := url.Parse(c.API.BaseURL)
+	check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+		"api.base_url: %q is not an http(s) URL", c.API.BaseURL)
+	check(c.API.Timeout > 0, "api.timeout: must be positive")
+	check(c.API.Retries >= 0, "api.retries: must not be negative")
+	if c.API.Retries > 0 {
+		check(c.API.RetryBackoff > 0, "api.retry_backoff: must be positive")
+	}
+	check(c.API.Record == "" || c.API.Replay == "", "api.record and api.replay: only one may be set")
+
+	_, known := transportNames[c.Server.Transport]
+	check(known || c.Server.Transport == "stdio" || c.Server.Transport == "unix",
+		"server.transport: unknown transport %q (use stdio, http, sse, combined, websocket or unix)", c.Server.Transport)
+	_, err = strconv.ParseUint(c.Server.SocketMode, 8, 32)
+	check(err == nil, "server.socket_mode: %q is not an octal file mode", c.Server.SocketMode)
+
+	check(c.Sessions.StreamRetention > 0, "sessions.stream_retention: must be positive")
+	check(c.RateLimit.PerMinute >= 0, "rate_limit.per_minute: must not be negative")
+	check(c.RateLimit.Burst > 0, "rate_limit.burst: must be positive")
+	check(c.Breaker.FailureRatio >= 0 && c.Breaker.FailureRatio <= 1, "breaker.failure_ratio: must be between 0 and 1")
+	if c.Breaker.FailureRatio > 0 {
+		check(c.Breaker.MinRequests > 0, "breaker.min_requests: must be positive")
+		check(c.Breaker.Window > 0, "breaker.window: must be positive")
+		check(c.Breaker.OpenDuration > 0, "breaker.open_duration: must be positive")
+		check(c.Breaker.HalfOpenProbes > 0, "breaker.half_open_probes: must be positive")
+	}
+	check(c.Budgets.Session >= 0, "budgets.session: must not be negative")
+	check(c.Cache.TTL >= 0, "cache.ttl: must not be negative")
+	if c.Cache.TTL > 0 {
+		check(c.Cache.MaxEntries > 0, "cache.max_entries: must be positive")
+	}
+
+	for _, p := range append(append([]string(nil), c.Tools.Include...), c.Tools.Exclude...) {
+		_, err := path.Match(p, "")
+		check(err == nil, "tools: invalid pattern %q", p)
+	}
+	return errors.Join(errs...)
+}
+
+// apply configures the package-level settings used by tool calls. The
+// returned cleanup closes the usage ledger and log file.
+func (c *config) apply() (cleanup func()) {
+	setLogFile(c.Logging.File)
+
+	interzoidBaseURL = strings.TrimRight(c.API.BaseURL, "/")
+	httpClient.Timeout = c.API.Timeout
+	httpClient.Transport = c.API.transport()
+	defaultAPIKey = c.API.Key
+	defaultUsageTag = c.Usage.Tag
+	trustProxyHeaders = c.Server.TrustProxy
+	streamRetention = c.Sessions.StreamRetention
+	c.applyReloadable()
+
+	if c.Budgets.Session > 0 && c.Sessions.Stateless {
+		// Stateless requests carry whatever session ID the client sends
+		log.Println("Session budget disabled: budgets.session has no effect with sessions.stateless")
+	}
+
+	if c.Usage.Ledger != "" {
+		l, err := openLedger(c.Usage.Ledger)
+		if err != nil {
+			log.Printf("Usage ledger disabled: %v\n", err)
+		} else {
+			usageLedger = l
+			return func() {
+				usageLedger.close()
+				setLogFile("")
+			}
+		}
+	}
+	return func() { setLogFile("") }
+}
+
+// transport returns the HTTP transport for upstream calls: a cassette when
+// recording or replaying, otherwise nil for the default transport. A
+// cassette that cannot be read for replay fails every call rather than
+// letting it reach the network.
+func (a apiSettings) transport() http.RoundTripper {
+	if a.Record == "" && a.Replay == "" {
+		return nil
+	}
+	path, replay := a.Record, false
+	if a.Replay != "" {
+		path, replay = a.Replay, true
+	}
+
+	t, err := newCassetteTransport(path, replay, http.DefaultTransport)
+	if err != nil {
+		log.Printf("%v\n", err)
+		if !replay {
+			log.Println("Recording disabled")
+			return nil
+		}
+		return &cassetteTransport{path: path, replay: true, replayed: make(map[string]int)}
+	}
+	if replay {
+		log.Printf("Replaying %d upstream responses from %s\n", len(t.tape.Interactions), path)
+	} else {
+		log.Printf("Recording upstream traffic to %s\n", path)
+	}
+	return t
+}
+
+// applyReloadable configures the settings a configuration reload can change
+// while the server runs: the rate limit, circuit breakers, credit guard,
+// session budget and response cache. The limiter, breakers and upstream
+// client (holding the cache) are replaced, losing their state. The tool
+// filter belongs to the server, which is built with it (see buildServer).
+func (c *config) applyReloadable() {
+	creditGuard.Store(c.Credits.Guard)
+	creditGuardFailClosed.Store(c.Credits.FailClosed)
+	sessionBudget.Store(c.budget())
+	inboundLimiter.Store(c.RateLimit.limiter())
+	upstreamBreakers.Store(c.Breaker.breakers())
+	upstreamClient.Store(c.client())
+}
+
+// budget returns the session budget in atomic USDC, or 0 when there is
+// none. Budgets are not enforced for stateless sessions.
+func (c *config) budget() int64 {
+	if c.Sessions.Stateless {
+		return 0
+	}
+	return int64(math.Round(c.Budgets.Session * 1e6))
+}
+
+// limiter returns the rate limiter the settings describe, or nil when rate
+// limiting is off.
+func (r rateLimitSettings) limiter() *rateLimiter {
+	if r.PerMinute <= 0 {
+		return nil
+	}
+	return newRateLimiter(r.PerMinute, r.Burst, r.PerTool)
+}
+
+// breakers returns the circuit breakers the settings describe, or nil when
+// they are off.
+func (b breakerSettings) breakers() *breakerSet {
+	if b.FailureRatio <= 0 {
+		return nil
+	}
+	return newBreakerSet(breakerConfig{
+		failureRatio:   b.FailureRatio,
+		minRequests:    b.MinRequests,
+		window:         b.Window,
+		openDuration:   b.OpenDuration,
+		halfOpenProbes: b.HalfOpenProbes,
+	})
+}
+
+// client returns the Interzoid client the settings describe, retrying and
+// caching responses as configured.
+func (c *config) client() *interzoid.Client {
+	return interzoid.NewClient(
+		interzoid.WithBaseURL(c.API.BaseURL),
+		interzoid.WithHTTPClient(httpClient),
+		interzoid.WithRetry(c.API.Retries, c.API.RetryBackoff),
+		interzoid.WithCache(c.Cache.TTL, c.Cache.MaxEntries),
+	)
+}
+
+// logFile is the open log file, if logging.file is set.
+var logFile *os.File
+
+// setLogFile sends the log to path, or to stderr when path is empty, closing
+// the previous log file. Reopening the same path on a reload lets log
+// rotation move the old file away.
+func setLogFile(path string) {
+	previous := logFile
+	logFile = nil
+	if path == "" {
+		log.SetOutput(os.Stderr)
+	} else if f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600); err != nil {
+		log.SetOutput(os.Stderr)
+		log.Printf("Logging to stderr: %v\n", err)
+	} else {
+		log.SetOutput(f)
+		logFile = f
+	}
+	if previous != nil {
+		previous.Close()
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Tool filter
+// ---------------------------------------------------------------------------
+
+// allows reports whether the filter selects the named tool.
+func (f toolFilter) allows(name string) bool {
+	matches := func(patterns []string) bool {
+		for _, p := range patterns {
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	return (len(f.Include) == 0 || matches(f.Include)) && !matches(f.Exclude)
+}
+
+// filterTools removes the tools allows does not select from the server and
+// its catalog.
+func filterTools(s *server.MCPServer, catalog *toolCatalog, allows func(name string) bool) {
+	var removed []string
+	for name := range s.ListTools() {
+		if !allows(name) {
+			removed = append(removed, name)
+		}
+	}
+	if len(removed) == 0 {
+		return
+	}
+	s.DeleteTools(removed...)
+	catalog.remove(removed...)
+}
+
+// ---------------------------------------------------------------------------
+// Environment
+// ---------------------------------------------------------------------------
+
+// configField is one leaf setting of the configuration.
+type configField struct {
+	key    string // dotted YAML key, e.g. rate_limit.per_minute
+	env    string // environment variable, e.g. INTERZOID_RATE_LIMIT_PER_MINUTE
+	secret bool
+	value  reflect.Value
+}
+
+// configFields lists the leaf settings of cfg.
+func configFields(cfg *config) []configField {
+	var fields []configField
+	sections := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		if !sections.Type().Field(i).IsExported() {
+			continue
+		}
+		section := sections.Field(i)
+		sectionKey := sections.Type().Field(i).Tag.Get("yaml")
+		for j := 0; j < section.NumField(); j++ {
+			f := section.Type().Field(j)
+			key := sectionKey + "_" + f.Tag.Get("yaml")
+			fields = append(fields, configField{
+				key:    sectionKey + "." + f.Tag.Get("yaml"),
+				env:    configEnvPrefix + strings.ToUpper(key),
+				secret: f.Tag.Get("secret") == "true",
+				value:  section.Field(j),
+			})
+		}
+	}
+	return fields
+}
+
+// applyEnv overrides settings from INTERZOID_* environment variables.
+func applyEnv(cfg *config) error {
+	var errs []error
+	for _, f := range configFields(cfg) {
+		raw, ok := os.LookupEnv(f.env)
+		if !ok {
+			continue
+		}
+		if err := setField(f.value, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.env, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// setField parses an environment value into a setting. Lists are
+// comma-separated.
+func setField(v reflect.Value, raw string) error {
+	switch v.Interface().(type) {
+	case string:
+		v.SetString(raw)
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		v.SetBool(b)
+	case int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		v.SetInt(int64(n))
+	case float64:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		v.SetFloat(n)
+	case time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", raw)
+		}
+		v.SetInt(int64(d))
+	case []string:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// unknownEnv returns INTERZOID_* environment variables that name no
+// setting, which are usually typos.
+func unknownEnv(cfg *config) []string {
+	known := map[string]bool{configEnvPrefix + "CONFIG": true}
+	for _, f := range configFields(cfg) {
+		known[f.env] = true
+	}
+	var unknown []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(name, configEnvPrefix) && !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// ---------------------------------------------------------------------------
+// CONFIG SUBCOMMAND
+// ---------------------------------------------------------------------------
+
+// runConfigCommand implements `config check`: it validates the configuration
+// and prints the merged result with secrets masked.
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "Usage: interzoid-mcp-server config check [-config file]")
+		return 2
+	}
+	fs := flag.NewFlagSet("config check", flag.ContinueOnError)
+	cfg, err := loadConfigFlag(fs, args[1:])
+	if parseErr := fs.Parse(args[1:]); parseErr != nil {
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	for _, name := range unknownEnv(cfg) {
+		fmt.Fprintf(os.Stderr, "Warning: %s is not a known setting\n", name)
+	}
+
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(maskSecrets(cfg)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// maskSecrets returns a copy of cfg with secret settings masked, keeping the
+// last four characters of long values so keys can be told apart.
+func maskSecrets(cfg *config) *config {
+	masked := *cfg
+	for _, f := range configFields(&masked) {
+		if !f.secret || f.value.String() == "" {
+			continue
+		}
+		s := f.value.String()
+		if len(s) > 8 {
+			f.value.SetString("****" + s[len(s)-4:])
+		} else {
+			f.value.SetString("****")
+		}
+	}
+	return &masked
+}
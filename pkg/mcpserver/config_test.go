@@ -0,0 +1,232 @@
+package mcpserver
+
+import (
+	"context"
+	"flag"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// writeConfig writes a configuration file and returns its path.
+func writeConfig(t *testing.T, yaml string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigMergeOrder(t *testing.T) {
+	path := writeConfig(t, `
+api:
+  key: file-key
+  timeout: 5s
+rate_limit:
+  per_minute: 30
+tools:
+  exclude: ["interzoid_gender"]
+`)
+	t.Setenv("INTERZOID_API_KEY", "env-key")
+	t.Setenv("INTERZOID_TOOLS_INCLUDE", "interzoid_*_match*, interzoid_gender")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	args := []string{"-config", path, "-rate-limit", "90"}
+	cfg, err := loadConfigFlag(fs, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addCallFlags(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.API.Key != "env-key" {
+		t.Errorf("api.key = %q, want the environment to win over the file", cfg.API.Key)
+	}
+	if cfg.API.Timeout != 5*time.Second {
+		t.Errorf("api.timeout = %v, want 5s from the file", cfg.API.Timeout)
+	}
+	if cfg.RateLimit.PerMinute != 90 {
+		t.Errorf("rate_limit.per_minute = %v, want the flag to win over the file", cfg.RateLimit.PerMinute)
+	}
+	if cfg.RateLimit.Burst != 10 {
+		t.Errorf("rate_limit.burst = %d, want the default 10", cfg.RateLimit.Burst)
+	}
+	if got := strings.Join(cfg.Tools.Include, " "); got != "interzoid_*_match* interzoid_gender" {
+		t.Errorf("tools.include = %q", got)
+	}
+	if err := cfg.validate(); err != nil {
+		t.Errorf("validate: %v", err)
+	}
+}
+
+func TestLoadConfigUnknownKey(t *testing.T) {
+	path := writeConfig(t, "api:\n  base_ur1: https://example.com\n")
+	if _, err := loadConfig(path); err == nil || !strings.Contains(err.Error(), "base_ur1") {
+		t.Errorf("err = %v, want the unknown key reported", err)
+	}
+}
+
+func TestLoadConfigBadEnv(t *testing.T) {
+	t.Setenv("INTERZOID_CACHE_TTL", "an hour")
+	if _, err := loadConfig(""); err == nil || !strings.Contains(err.Error(), "INTERZOID_CACHE_TTL") {
+		t.Errorf("err = %v, want the bad variable reported", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.API.BaseURL = "api.interzoid.com"
+	cfg.Server.Transport = "carrier-pigeon"
+	cfg.Server.SocketMode = "rw-rw----"
+	cfg.API.Retries = -1
+	cfg.Breaker.FailureRatio = 2
+	cfg.Cache.TTL = time.Hour
+	cfg.Cache.MaxEntries = 0
+	cfg.Tools.Exclude = []string{"interzoid_["}
+
+	err := cfg.validate()
+	if err == nil {
+		t.Fatal("invalid configuration passed validation")
+	}
+	for _, key := range []string{"api.base_url", "api.retries", "server.transport", "server.socket_mode", "breaker.failure_ratio", "cache.max_entries", "tools:"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("%s not reported in %v", key, err)
+		}
+	}
+
+	if err := defaultConfig().validate(); err != nil {
+		t.Errorf("defaults are invalid: %v", err)
+	}
+}
+
+// Tool calls are made with the configured client, which retries and caches.
+func TestConfiguredUpstreamClient(t *testing.T) {
+	resetReloadable(t)
+	var failed atomic.Bool
+	calls := stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		if failed.CompareAndSwap(false, true) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"Gender":"F","Code":"Success"}`)
+	})
+	cfg := defaultConfig()
+	cfg.API.Retries, cfg.API.RetryBackoff = 1, time.Millisecond
+	cfg.Cache.TTL = time.Hour
+	cfg.applyReloadable()
+
+	header := http.Header{"Authorization": {"Bearer key"}}
+	for i := 0; i < 2; i++ {
+		result, err := callToolHandler(context.Background(), t, "interzoid_gender", map[string]interface{}{"name": "Maria"}, header)
+		if err != nil || result.IsError {
+			t.Fatalf("call %d = %v, %s", i, err, resultText(result))
+		}
+	}
+	if calls() != 2 {
+		t.Errorf("upstream calls = %d, want a failure and its retry, then a cached answer", calls())
+	}
+}
+
+// The credit guard reads a live balance even with the response cache on.
+func TestConfiguredUpstreamClientSkipsCredits(t *testing.T) {
+	resetReloadable(t)
+	calls := stubUpstream(t, answer(`{"Credits":"100","Code":"Success"}`))
+	cfg := defaultConfig()
+	cfg.Cache.TTL = time.Hour
+	cfg.applyReloadable()
+
+	for i := 0; i < 2; i++ {
+		if _, err := refreshCredits(context.Background(), "key"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls() != 2 {
+		t.Errorf("upstream calls = %d, want a fresh balance each time", calls())
+	}
+}
+
+func TestMaskSecrets(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.API.Key = "0123456789abcdef"
+	masked := maskSecrets(cfg)
+	if masked.API.Key != "****cdef" {
+		t.Errorf("masked key = %q", masked.API.Key)
+	}
+	if cfg.API.Key != "0123456789abcdef" {
+		t.Error("maskSecrets changed the original configuration")
+	}
+
+	cfg.API.Key = "short"
+	if got := maskSecrets(cfg).API.Key; got != "****" {
+		t.Errorf("masked short key = %q", got)
+	}
+}
+
+func TestUnknownEnv(t *testing.T) {
+	t.Setenv("INTERZOID_API_KYE", "typo")
+	t.Setenv("INTERZOID_API_KEY", "key")
+	unknown := unknownEnv(defaultConfig())
+	if len(unknown) != 1 || unknown[0] != "INTERZOID_API_KYE" {
+		t.Errorf("unknownEnv = %v", unknown)
+	}
+}
+
+func TestToolFilter(t *testing.T) {
+	f := toolFilter{
+		Include: []string{"interzoid_*_match*"},
+		Exclude: []string{"interzoid_product_match"},
+	}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"interzoid_company_match_advanced", true},
+		{"interzoid_fullname_match_score", true},
+		{"interzoid_product_match", false},
+		{"interzoid_gender", false},
+	}
+	for _, tt := range tests {
+		if got := f.allows(tt.name); got != tt.want {
+			t.Errorf("allows(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if !(toolFilter{}).allows("interzoid_gender") {
+		t.Error("an empty filter excluded a tool")
+	}
+}
+
+func TestFilterToolsRemovesPrompts(t *testing.T) {
+	s, _ := buildServer(toolFilter{Exclude: []string{"interzoid_org_match_score"}}, nil)
+	if s.GetTool("interzoid_org_match_score") != nil {
+		t.Error("excluded tool is still offered")
+	}
+	if s.GetTool("interzoid_company_match_advanced") == nil {
+		t.Error("a tool that was not excluded is missing")
+	}
+
+	message := []byte(`{"jsonrpc":"2.0","id":1,"method":"prompts/list"}`)
+	response, ok := s.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("prompts/list failed: %#v", response)
+	}
+	prompts := map[string]bool{}
+	for _, p := range response.Result.(mcp.ListPromptsResult).Prompts {
+		prompts[p.Name] = true
+	}
+	if prompts["deduplicate_company_list"] {
+		t.Error("a prompt naming the excluded tool is still offered")
+	}
+	if !prompts["kyb_vendor_check"] {
+		t.Error("a prompt whose tools are all enabled is missing")
+	}
+}
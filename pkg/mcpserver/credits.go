@@ -0,0 +1,172 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// creditsEndpoint returns the remaining credit balance for an API key.
+const creditsEndpoint = "/getremainingcredits"
+
+// creditBalanceTTL is how long a cached balance is trusted by the pre-flight
+// guard before it is re-fetched from Interzoid.
+const creditBalanceTTL = 5 * time.Minute
+
+// creditGuard enables the pre-flight check that refuses calls whose estimated
+// credit use exceeds the caller's remaining balance.
+var creditGuard atomic.Bool
+
+// creditGuardFailClosed makes the guard refuse calls whose balance cannot be
+// determined, instead of letting them through.
+var creditGuardFailClosed atomic.Bool
+
+// creditBalances caches the last known credit balance per API key. It is
+// refreshed from the "Credits" field Interzoid includes in normal responses,
+// so it stays current without extra upstream calls. Balances are keyed by
+// caller ID (see keyID) so the keys themselves are not held.
+var creditBalances = newCreditCache()
+
+// callCredits returns the balance cache of the server handling a call: its
+// own for a server built by NewServer, otherwise the command's.
+func callCredits(ctx context.Context) *creditCache {
+	if state, ok := ctx.Value(serverStateKey).(*serverState); ok {
+		return state.credits
+	}
+	return creditBalances
+}
+
+type creditBalance struct {
+	credits int64
+	updated time.Time
+}
+
+type creditCache struct {
+	mu       sync.Mutex
+	balances map[string]creditBalance
+}
+
+func newCreditCache() *creditCache {
+	return &creditCache{balances: make(map[string]creditBalance)}
+}
+
+// observe updates the cached balance for apiKey from an API response, if the
+// response carries a Credits field.
+func (c *creditCache) observe(apiKey string, result map[string]interface{}) {
+	if apiKey == "" {
+		return
+	}
+	credits, ok := parseCredits(result["Credits"])
+	if !ok {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.balances[keyID(apiKey)] = creditBalance{credits: credits, updated: time.Now()}
+}
+
+// get returns the cached balance for apiKey.
+func (c *creditCache) get(apiKey string) (creditBalance, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b, ok := c.balances[keyID(apiKey)]
+	return b, ok
+}
+
+// parseCredits accepts the Credits field as either a JSON number or a
+// numeric string, since Interzoid returns it as a string.
+func parseCredits(v interface{}) (int64, bool) {
+	switch c := v.(type) {
+	case float64:
+		return int64(c), true
+	case string:
+		n, err := strconv.ParseInt(c, 10, 64)
+		return n, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// refreshCredits fetches the current balance for apiKey from Interzoid and
+// updates the cache.
+func refreshCredits(ctx context.Context, apiKey string) (map[string]interface{}, error) {
+	result, err := callInterzoidAPI(ctx, apiKey, creditsEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	callCredits(ctx).observe(apiKey, result)
+	return result, nil
+}
+
+// checkCredits is the pre-flight guard. It returns an error when the guard is
+// enabled and the estimated credit use exceeds the remaining balance. Calls
+// without an API key (x402) are not credit-based and always pass. Calls whose
+// balance cannot be determined pass too, unless the guard fails closed
+// (-credit-guard-fail-closed).
+func checkCredits(ctx context.Context, apiKey string, estimated int64) error {
+	if !creditGuard.Load() || apiKey == "" || estimated == 0 {
+		return nil
+	}
+
+	balances := callCredits(ctx)
+	b, ok := balances.get(apiKey)
+	if !ok || time.Since(b.updated) > creditBalanceTTL {
+		_, err := refreshCredits(ctx, apiKey)
+		if err == nil {
+			b, ok = balances.get(apiKey)
+			if !ok {
+				err = errors.New("the response had no Credits field")
+			}
+		}
+		if err != nil {
+			if !creditGuardFailClosed.Load() {
+				return nil
+			}
+			return fmt.Errorf("could not check the Interzoid credit balance before the call: %w", err)
+		}
+	}
+
+	if estimated > b.credits {
+		return newToolError(codeOutOfCredits, "insufficient Interzoid credits: this request needs an estimated %d credits but only %d remain", estimated, b.credits)
+	}
+	return nil
+}
+
+// remainingCreditsHandler reports the credit balance for the caller's API key.
+func remainingCreditsHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	apiKey := getAPIKey(ctx, request)
+	if apiKey == "" {
+		return toolErrorResult(newToolError(codeAuthFailed, "Remaining credits are only available with an API key. Without one, calls are paid per request via x402 and no credit balance applies.")), nil
+	}
+
+	if err := checkRateLimit(ctx, request); err != nil {
+		return toolErrorResult(err), nil
+	}
+
+	start := time.Now()
+	result, err := refreshCredits(ctx, apiKey)
+	recordUsage(ctx, request, creditsEndpoint, apiKey, start, result, err, false)
+	if err != nil {
+		return toolErrorResult(err), nil
+	}
+
+	if b, ok := callCredits(ctx).get(apiKey); ok {
+		result["standardCallsRemaining"] = b.credits / standardTier.credits
+		result["premiumCallsRemaining"] = b.credits / premiumTier.credits
+	}
+
+	jsonBytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return toolErrorResult(fmt.Errorf("failed to format response: %w", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
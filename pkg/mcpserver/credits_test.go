@@ -0,0 +1,185 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// withCreditGuard enables the credit guard with an empty balance cache for
+// the rest of the test.
+func withCreditGuard(t *testing.T, failClosed bool) {
+	t.Helper()
+	creditGuard.Store(true)
+	creditGuardFailClosed.Store(failClosed)
+	previous := creditBalances
+	creditBalances = &creditCache{balances: make(map[string]creditBalance)}
+	t.Cleanup(func() {
+		creditGuard.Store(false)
+		creditGuardFailClosed.Store(false)
+		creditBalances = previous
+	})
+}
+
+func TestParseCredits(t *testing.T) {
+	tests := []struct {
+		in     interface{}
+		want   int64
+		wantOK bool
+	}{
+		{"4997", 4997, true},
+		{float64(12), 12, true},
+		{"lots", 0, false},
+		{nil, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseCredits(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseCredits(%v) = %d, %v, want %d, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestCreditCacheKeyedByCallerID(t *testing.T) {
+	c := &creditCache{balances: make(map[string]creditBalance)}
+	c.observe("secret-key", map[string]interface{}{"Credits": "40"})
+	c.observe("", map[string]interface{}{"Credits": "99"})
+
+	if b, ok := c.get("secret-key"); !ok || b.credits != 40 {
+		t.Errorf("balance = %+v, %v, want 40", b, ok)
+	}
+	for id := range c.balances {
+		if strings.Contains(id, "secret-key") {
+			t.Errorf("balance keyed by the raw API key: %q", id)
+		}
+	}
+	if len(c.balances) != 1 {
+		t.Errorf("balances = %v, want only the keyed one", c.balances)
+	}
+}
+
+func TestCheckCredits(t *testing.T) {
+	tests := []struct {
+		name       string
+		upstream   http.HandlerFunc
+		failClosed bool
+		apiKey     string
+		estimated  int64
+		wantErr    string
+	}{
+		{"enough credits", credits("30"), false, "key", 25, ""},
+		{"too few credits", credits("10"), false, "key", 25, "insufficient Interzoid credits"},
+		{"x402 calls are not credit-based", credits("0"), false, "", 25, ""},
+		{"balance unavailable, fail open", unavailable, false, "key", 25, ""},
+		{"balance unavailable, fail closed", unavailable, true, "key", 25, "could not check the Interzoid credit balance"},
+		{"no Credits field, fail closed", answer(`{"Code":"Success"}`), true, "key", 1, "no Credits field"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubUpstream(t, tt.upstream)
+			withCreditGuard(t, tt.failClosed)
+
+			err := checkCredits(context.Background(), tt.apiKey, tt.estimated)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("err = %v, want none", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// The guard refuses a premium call the balance cannot cover without
+// calling the API, and learns balances from ordinary responses.
+func TestCreditGuardToolCall(t *testing.T) {
+	calls := stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"Gender":"F","Code":"Success","Credits":"20"}`)
+	})
+	withCreditGuard(t, false)
+	header := http.Header{"Authorization": {"Bearer key"}}
+
+	// The first call fetches the balance, then makes the call
+	if result, err := callToolHandler(context.Background(), t, "interzoid_gender", map[string]interface{}{"name": "Maria"}, header); err != nil || result.IsError {
+		t.Fatalf("standard call failed: %v %s", err, resultText(result))
+	}
+	before := calls()
+
+	result, err := callToolHandler(context.Background(), t, "interzoid_business_info", map[string]interface{}{"lookup": "cisco.com"}, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError || !strings.Contains(resultText(result), "only 20 remain") {
+		t.Errorf("premium call result = %s, want an insufficient credits error", resultText(result))
+	}
+	if calls() != before {
+		t.Error("the refused call reached the API")
+	}
+}
+
+func TestRemainingCreditsTool(t *testing.T) {
+	stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != creditsEndpoint || r.Header.Get("x-api-key") != "key" {
+			t.Errorf("request = %s with key %q", r.URL, r.Header.Get("x-api-key"))
+		}
+		io.WriteString(w, `{"Code":"Success","Credits":"60"}`)
+	})
+
+	result, err := callToolHandler(context.Background(), t, "interzoid_remaining_credits", nil, http.Header{"Authorization": {"Bearer key"}})
+	if err != nil || result.IsError {
+		t.Fatalf("call failed: %v %s", err, resultText(result))
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(resultText(result)), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["standardCallsRemaining"] != float64(60) || got["premiumCallsRemaining"] != float64(2) {
+		t.Errorf("result = %v, want 60 standard and 2 premium calls", got)
+	}
+
+	t.Setenv("INTERZOID_API_KEY", "")
+	result, err = callToolHandler(context.Background(), t, "interzoid_remaining_credits", nil, nil)
+	if err != nil || !result.IsError {
+		t.Errorf("call without a key = %v %s, want a tool error", err, resultText(result))
+	}
+}
+
+func TestRemainingCreditsRateLimited(t *testing.T) {
+	calls := stubUpstream(t, credits("60"))
+	inboundLimiter.Store(newRateLimiter(60, 1, false))
+	t.Cleanup(func() { inboundLimiter.Store(nil) })
+
+	header := http.Header{"Authorization": {"Bearer key"}}
+	if _, err := callToolHandler(context.Background(), t, "interzoid_remaining_credits", nil, header); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	result, err := callToolHandler(context.Background(), t, "interzoid_remaining_credits", nil, header)
+	if err != nil || !result.IsError {
+		t.Fatalf("second call = %v, %v, want a tool error", resultText(result), err)
+	}
+	if body := parseToolError(resultText(result)); body.Code != codeRateLimited {
+		t.Errorf("error = %+v, want rate_limited", body)
+	}
+	if calls() != 1 {
+		t.Errorf("upstream calls = %d, want 1", calls())
+	}
+}
+
+// answer returns an upstream handler replying with body.
+func answer(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, body) }
+}
+
+// credits returns an upstream handler reporting a credit balance.
+func credits(balance string) http.HandlerFunc {
+	return answer(`{"Code":"Success","Credits":"` + balance + `"}`)
+}
+
+// unavailable is an upstream handler failing every call.
+func unavailable(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusServiceUnavailable)
+	io.WriteString(w, "unavailable")
+}
@@ -0,0 +1,127 @@
+package mcpserver
+
+import (
+	"context"
+
+	"github.com/interzoid/interzoid-mcp-server/pkg/interzoid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============================================================================
+// EMBEDDING
+// ============================================================================
+//
+// NewServer returns an MCP server built as the command's is, with the
+// Interzoid tools, resources, prompts and completions. Go programs can serve
+// it as it is, or copy its tools next to their own:
+//
+//	tools := mcpserver.NewServer(
+//		mcpserver.WithTools([]string{"interzoid_*_match*"}, nil),
+//		mcpserver.WithKeyFunc(keyForTenant),
+//	)
+//	for _, tool := range tools.ListTools() {
+//		mine.AddTools(*tool)
+//	}
+//
+// The options are bound into each tool's handler, so they still apply when
+// the tools are copied into another server this way; the server's own
+// middleware, which attaches WebSocket connection headers, does not travel
+// with them. Each server has its own tool list, catalog, credit balances and
+// session spend, and none reads the environment: without WithKeyFunc, calls
+// use the caller's Authorization header or x402 payment. The command's
+// process-wide settings (rate limits, circuit breakers, response cache,
+// credit guard, session budgets, usage ledger and the /debug/vars metrics)
+// have no options and are off in an embedding program; WithMiddleware can
+// add limits, and a client given to WithClient can retry and cache.
+// ============================================================================
+
+// KeyFunc returns the Interzoid API key for a tool call. An empty key makes
+// the call with x402 payment, or with the key of the client given to
+// WithClient.
+type KeyFunc func(ctx context.Context, request mcp.CallToolRequest) string
+
+// Option configures a server built by NewServer.
+type Option func(*embedOptions)
+
+type embedOptions struct {
+	tools       toolFilter
+	middlewares []server.ToolHandlerMiddleware
+	keyFunc     KeyFunc
+	client      *interzoid.Client
+	state       *serverState
+}
+
+// serverState is what a server built by NewServer keeps for itself rather
+// than share with the command or other servers in the process.
+type serverState struct {
+	sessions sessionStore
+	credits  *creditCache
+}
+
+// WithTools selects the tools to offer with shell-style patterns, as the
+// tools.include and tools.exclude settings do. An empty include offers
+// every tool, and exclude wins over include.
+func WithTools(include, exclude []string) Option {
+	return func(o *embedOptions) { o.tools = toolFilter{Include: include, Exclude: exclude} }
+}
+
+// WithMiddleware wraps every tool handler, the first middleware outermost.
+// It may be given several times.
+func WithMiddleware(mw ...server.ToolHandlerMiddleware) Option {
+	return func(o *embedOptions) { o.middlewares = append(o.middlewares, mw...) }
+}
+
+// WithKeyFunc resolves the API key of each call with f instead of
+// DefaultKey.
+func WithKeyFunc(f KeyFunc) Option {
+	return func(o *embedOptions) { o.keyFunc = f }
+}
+
+// WithClient makes every upstream call with client, for its base URL, HTTP
+// client, retries and cache. The key of each call is applied with
+// Client.WithKey.
+func WithClient(client *interzoid.Client) Option {
+	return func(o *embedOptions) { o.client = client }
+}
+
+// NewServer returns an MCP server offering the Interzoid API tools and the
+// server's own tools (upstream status, remaining credits, usage report),
+// configured by opts. It is built exactly as the command's server is, with
+// the same middleware, resources, prompts and completions.
+func NewServer(opts ...Option) *server.MCPServer {
+	o := embedOptions{state: &serverState{sessions: newMemorySessionStore(), credits: newCreditCache()}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	s, _ := buildServer(o.tools, &o)
+	return s
+}
+
+// wrapTools binds the options into the handler of every tool on s.
+func (o *embedOptions) wrapTools(s *server.MCPServer) {
+	var tools []server.ServerTool
+	for _, tool := range s.ListTools() {
+		handler := o.bind(tool.Handler)
+		for i := len(o.middlewares) - 1; i >= 0; i-- {
+			handler = o.middlewares[i](handler)
+		}
+		tools = append(tools, server.ServerTool{Tool: tool.Tool, Handler: handler})
+	}
+	s.AddTools(tools...)
+}
+
+// bind makes the server's state, key function and client available to a
+// handler.
+func (o *embedOptions) bind(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		ctx = context.WithValue(ctx, serverStateKey, o.state)
+		if o.keyFunc != nil {
+			ctx = context.WithValue(ctx, keyFuncKey, o.keyFunc)
+		}
+		if o.client != nil {
+			ctx = context.WithValue(ctx, apiClientKey, o.client)
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,179 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/interzoid/interzoid-mcp-server/pkg/interzoid"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// listResult sends a list request to s and returns its result as JSON.
+func listResult(t *testing.T, s *server.MCPServer, method string) string {
+	t.Helper()
+	message := []byte(`{"jsonrpc":"2.0","id":1,"method":"` + method + `"}`)
+	response, ok := s.HandleMessage(context.Background(), message).(mcp.JSONRPCResponse)
+	if !ok {
+		t.Fatalf("%s failed: %#v", method, response)
+	}
+	data, err := json.Marshal(response.Result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// NewServer offers the same tools, resources and prompts as the command's
+// server.
+func TestNewServerMatchesCommand(t *testing.T) {
+	for _, method := range []string{"tools/list", "resources/list", "resources/templates/list", "prompts/list"} {
+		if got, want := listResult(t, NewServer(), method), listResult(t, newTestServer(), method); got != want {
+			t.Errorf("%s differs:\n got %s\nwant %s", method, got, want)
+		}
+	}
+}
+
+// A server built with a tool filter leaves the catalog of every other server
+// alone.
+func TestNewServerOwnCatalog(t *testing.T) {
+	command := newTestServer()
+	NewServer(WithTools([]string{"interzoid_gender"}, nil))
+
+	if command.GetTool("interzoid_org_match_score") == nil {
+		t.Fatal("another server's filter removed a tool")
+	}
+	for _, uri := range []string{catalogURI, pricingURI} {
+		text, errMsg := handle(t, command, "resources/read", map[string]string{"uri": uri})
+		if errMsg != "" {
+			t.Fatal(errMsg)
+		}
+		if !strings.Contains(text, "interzoid_org_match_score") {
+			t.Errorf("another server's filter removed the tool from %s", uri)
+		}
+	}
+}
+
+func TestNewServerOptions(t *testing.T) {
+	stubUpstream(t, answer(`{"Gender":"F","Code":"Success"}`))
+	resetReloadable(t)
+
+	var called bool
+	var order []string
+	trace := func(name string) server.ToolHandlerMiddleware {
+		return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+			return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				order = append(order, name)
+				return next(ctx, request)
+			}
+		}
+	}
+	s := NewServer(
+		WithTools([]string{"interzoid_gender"}, nil),
+		WithKeyFunc(func(ctx context.Context, request mcp.CallToolRequest) string {
+			called = true
+			return defaultAPIKey
+		}),
+		WithMiddleware(trace("outer"), trace("inner")),
+	)
+
+	tools := s.ListTools()
+	if len(tools) != 1 || tools["interzoid_gender"] == nil {
+		t.Fatalf("tools = %v, want only interzoid_gender", tools)
+	}
+
+	// The options travel with the tool when it is copied into another server
+	mine := server.NewMCPServer("mine", "1.0.0", server.WithToolCapabilities(true))
+	mine.AddTools(*tools["interzoid_gender"])
+	result, err := callTool(context.Background(), mine, "interzoid_gender", map[string]interface{}{"name": "Maria"})
+	if err != nil || result.IsError {
+		t.Fatalf("call = %v, %v", resultText(result), err)
+	}
+	if !called {
+		t.Error("key function was not called")
+	}
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Errorf("middleware ran in order %v, want [outer inner]", order)
+	}
+}
+
+func TestNewServerWithClient(t *testing.T) {
+	var apiKey string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("x-api-key")
+		io.WriteString(w, `{"Gender":"F","Code":"Success"}`)
+	}))
+	t.Cleanup(upstream.Close)
+
+	client := interzoid.NewClient(interzoid.WithBaseURL(upstream.URL), interzoid.WithAPIKey("client-key"))
+	tests := []struct {
+		name, key, want string
+	}{
+		{"client's key", "", "client-key"},
+		{"resolved key", "tenant-key", "tenant-key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(
+				WithClient(client),
+				WithKeyFunc(func(ctx context.Context, request mcp.CallToolRequest) string { return tt.key }),
+			)
+			result, err := callTool(context.Background(), s, "interzoid_gender", map[string]interface{}{"name": "Maria"})
+			if err != nil || result.IsError {
+				t.Fatalf("call = %v, %v", resultText(result), err)
+			}
+			if apiKey != tt.want {
+				t.Errorf("upstream API key = %q, want %q", apiKey, tt.want)
+			}
+		})
+	}
+}
+
+// Servers built by NewServer keep their credit balances to themselves, and
+// building one publishes no metrics.
+func TestNewServerOwnState(t *testing.T) {
+	// Only the lookup reports a balance, so the credits tool shows what the
+	// server cached from it
+	stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == creditsEndpoint {
+			io.WriteString(w, `{"Code":"Success"}`)
+			return
+		}
+		io.WriteString(w, `{"Gender":"F","Credits":"500","Code":"Success"}`)
+	})
+	resetReloadable(t)
+	previous := creditBalances
+	creditBalances = newCreditCache()
+	t.Cleanup(func() { creditBalances = previous })
+
+	keyFunc := WithKeyFunc(func(ctx context.Context, request mcp.CallToolRequest) string { return "tenant-key" })
+	first, second := NewServer(keyFunc), NewServer(keyFunc)
+	result, err := callTool(context.Background(), first, "interzoid_gender", map[string]interface{}{"name": "Maria"})
+	if err != nil || result.IsError {
+		t.Fatalf("call = %v, %v", resultText(result), err)
+	}
+
+	if _, ok := creditBalances.get("tenant-key"); ok {
+		t.Error("the embedded server's balance reached the command's cache")
+	}
+	for name, s := range map[string]*server.MCPServer{"first": first, "second": second} {
+		result, err := callTool(context.Background(), s, "interzoid_remaining_credits", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := strings.Contains(resultText(result), "standardCallsRemaining")
+		if want := name == "first"; got != want {
+			t.Errorf("%s server reports a cached balance = %v, want %v: %s", name, got, want, resultText(result))
+		}
+	}
+
+	if expvar.Get("interzoid_circuit_breakers") != nil {
+		t.Error("circuit breaker metrics published outside Main")
+	}
+}
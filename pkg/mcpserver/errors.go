@@ -0,0 +1,181 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/interzoid/interzoid-mcp-server/pkg/interzoid"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// ============================================================================
+// TOOL ERRORS
+// ============================================================================
+//
+// Failed tool calls return a machine-readable error instead of a bare
+// message, as both the text content and the structured content of the
+// result:
+//
+//   {
+//     "error": "Interzoid API returned status 403: invalid license key",
+//     "code": "auth_failed",
+//     "hint": "Check the Interzoid API key ...",
+//     "retryable": false,
+//     "upstreamStatus": 403
+//   }
+//
+// code is stable and meant for agents and programs to branch on; error and
+// hint are for people and may change. Upstream failures are classified by
+// the Interzoid client (pkg/interzoid) from the HTTP status and, when the
+// body has them, the Interzoid Code and Message fields.
+// ============================================================================
+
+// errorCode identifies a class of tool failure. Upstream failures use the
+// codes of the Interzoid client; budget_exceeded and internal_error are the
+// server's own.
+type errorCode string
+
+const (
+	codeInvalidInput        = errorCode(interzoid.CodeInvalidInput)
+	codeAuthFailed          = errorCode(interzoid.CodeAuthFailed)
+	codeOutOfCredits        = errorCode(interzoid.CodeOutOfCredits)
+	codeRateLimited         = errorCode(interzoid.CodeRateLimited)
+	codeUpstreamUnavailable = errorCode(interzoid.CodeUpstreamUnavailable)
+	codeTimeout             = errorCode(interzoid.CodeTimeout)
+	codePaymentRequired     = errorCode(interzoid.CodePaymentRequired)
+	codeMalformedResponse   = errorCode(interzoid.CodeMalformedResponse)
+	codeBudgetExceeded      = errorCode("budget_exceeded")
+	codeInternal            = errorCode("internal_error")
+)
+
+// errorHints tells the agent how to recover from each class of failure.
+var errorHints = map[errorCode]string{
+	codeInvalidInput:        "Correct the arguments to match the tool's input schema and call the tool again.",
+	codeAuthFailed:          "Check the Interzoid API key (INTERZOID_API_KEY, or the Authorization header on a hosted server). Keys are available at https://www.interzoid.com.",
+	codeOutOfCredits:        "The account has run out of credits. Purchase more at https://www.interzoid.com, or reduce the number of lookups.",
+	codeRateLimited:         "Wait before retrying (retryAfterSeconds, when given), and slow down or batch the calls.",
+	codeUpstreamUnavailable: "The Interzoid API is temporarily unavailable. Retry later; interzoid_upstream_status shows which endpoints are failing.",
+	codeTimeout:             "The Interzoid API did not answer in time. Retry the call.",
+	codePaymentRequired:     "No API key was sent and the x402 payment requirements could not be read. Provide an Interzoid API key, or retry to pay with x402 USDC.",
+	codeMalformedResponse:   "The Interzoid API returned a response that could not be read. Retry, and report the error if it persists.",
+	codeBudgetExceeded:      "This session has spent its budget on this server. Ask the user before continuing in a new session, or make fewer or cheaper calls.",
+	codeInternal:            "The server could not complete the call. Retry, and report the error if it persists.",
+}
+
+// retryableCodes are failures that may succeed unchanged on a later attempt.
+var retryableCodes = map[errorCode]bool{
+	codeRateLimited:         true,
+	codeUpstreamUnavailable: true,
+	codeTimeout:             true,
+}
+
+// toolError is a classified tool failure.
+type toolError struct {
+	code           errorCode
+	message        string
+	hint           string        // overrides the code's default hint
+	upstreamStatus int           // HTTP status from Interzoid, if any
+	retryAfter     time.Duration // when known
+	err            error         // underlying cause, if any
+}
+
+func newToolError(code errorCode, format string, args ...interface{}) *toolError {
+	return &toolError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+func (e *toolError) Error() string { return e.message }
+
+func (e *toolError) Unwrap() error { return e.err }
+
+// toolErrorBody is the JSON form of a toolError in a tool result.
+type toolErrorBody struct {
+	Error             string    `json:"error"`
+	Code              errorCode `json:"code"`
+	Hint              string    `json:"hint"`
+	Retryable         bool      `json:"retryable"`
+	RetryAfterSeconds int       `json:"retryAfterSeconds,omitempty"`
+	UpstreamStatus    int       `json:"upstreamStatus,omitempty"`
+}
+
+// classifyError returns err as a toolError, classifying errors that are not
+// one already. A wrapped toolError keeps its code and takes the message of
+// the wrapping error.
+func classifyError(err error) *toolError {
+	var te *toolError
+	if errors.As(err, &te) {
+		if te == err {
+			return te
+		}
+		wrapped := *te
+		wrapped.message = err.Error()
+		return &wrapped
+	}
+
+	var apiErr *interzoid.Error
+	var open *circuitOpenError
+	var limited *rateLimitError
+	switch {
+	case errors.As(err, &apiErr):
+		// The client's codes are the tool error codes
+		te = &toolError{
+			code:           errorCode(apiErr.Code),
+			upstreamStatus: apiErr.StatusCode,
+			retryAfter:     apiErr.RetryAfter,
+		}
+	case errors.As(err, &open):
+		te = &toolError{code: codeUpstreamUnavailable, retryAfter: open.retryAfter}
+	case errors.As(err, &limited):
+		te = &toolError{code: codeRateLimited, retryAfter: limited.retryAfter}
+	case errors.Is(err, context.DeadlineExceeded):
+		te = &toolError{code: codeTimeout}
+	default:
+		te = &toolError{code: codeInternal}
+	}
+	te.message = err.Error()
+	te.err = err
+	return te
+}
+
+// body returns the JSON form of the error.
+func (e *toolError) body() toolErrorBody {
+	b := toolErrorBody{
+		Error:          e.message,
+		Code:           e.code,
+		Hint:           e.hint,
+		Retryable:      retryableCodes[e.code],
+		UpstreamStatus: e.upstreamStatus,
+	}
+	if b.Hint == "" {
+		b.Hint = errorHints[e.code]
+	}
+	if e.retryAfter > 0 {
+		b.RetryAfterSeconds = int(math.Ceil(e.retryAfter.Seconds()))
+	}
+	return b
+}
+
+// toolErrorResult reports err as a failed tool call.
+func toolErrorResult(err error) *mcp.CallToolResult {
+	body := classifyError(err).body()
+	jsonBytes, marshalErr := json.MarshalIndent(body, "", "  ")
+	if marshalErr != nil {
+		return mcp.NewToolResultError(body.Error)
+	}
+	result := mcp.NewToolResultError(string(jsonBytes))
+	result.StructuredContent = body
+	return result
+}
+
+// parseToolError reads the error body from the text of a failed tool call.
+// Errors not produced by toolErrorResult are reported as internal errors.
+func parseToolError(text string) toolErrorBody {
+	var body toolErrorBody
+	if json.Unmarshal([]byte(text), &body) != nil || body.Code == "" {
+		return toolErrorBody{Error: text, Code: codeInternal, Hint: errorHints[codeInternal]}
+	}
+	return body
+}
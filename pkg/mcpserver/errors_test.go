@@ -0,0 +1,71 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/interzoid/interzoid-mcp-server/pkg/interzoid"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want errorCode
+	}{
+		{"api error", &interzoid.Error{Code: interzoid.CodeAuthFailed, Message: "Interzoid API returned status 403: invalid license key", StatusCode: http.StatusForbidden}, codeAuthFailed},
+		{"tool error", fmt.Errorf("wrapped: %w", newToolError(codeOutOfCredits, "none left")), codeOutOfCredits},
+		{"circuit open", &circuitOpenError{endpoint: "getgender", retryAfter: time.Second}, codeUpstreamUnavailable},
+		{"rate limit", &rateLimitError{tool: "interzoid_gender", retryAfter: time.Second}, codeRateLimited},
+		{"deadline", fmt.Errorf("API request failed: %w", context.DeadlineExceeded), codeTimeout},
+		{"other", errors.New("boom"), codeInternal},
+	}
+	for _, tt := range tests {
+		te := classifyError(tt.err)
+		if te.code != tt.want || te.message != tt.err.Error() {
+			t.Errorf("%s: %s %q, want %s %q", tt.name, te.code, te.message, tt.want, tt.err)
+		}
+	}
+}
+
+func TestToolErrorResult(t *testing.T) {
+	result := toolErrorResult(&rateLimitError{tool: "interzoid_gender", retryAfter: 1500 * time.Millisecond})
+	if !result.IsError {
+		t.Fatal("result is not an error")
+	}
+	body, ok := result.StructuredContent.(toolErrorBody)
+	if !ok {
+		t.Fatalf("structured content = %T", result.StructuredContent)
+	}
+	if body.Code != codeRateLimited || !body.Retryable || body.RetryAfterSeconds != 2 || body.Hint != errorHints[codeRateLimited] {
+		t.Errorf("body = %+v", body)
+	}
+	if parsed := parseToolError(resultText(result)); parsed != body {
+		t.Errorf("text content parses to %+v, want %+v", parsed, body)
+	}
+	if parsed := parseToolError("plain failure"); parsed.Code != codeInternal || parsed.Error != "plain failure" {
+		t.Errorf("plain text parses to %+v", parsed)
+	}
+}
+
+// Upstream failures reach the agent classified, with the upstream status.
+func TestToolUpstreamFailure(t *testing.T) {
+	stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		io.WriteString(w, `{"Code":"Fail","Message":"Insufficient credits"}`)
+	})
+
+	result, err := callToolHandler(context.Background(), t, "interzoid_gender", map[string]interface{}{"name": "Maria"}, http.Header{"Authorization": {"Bearer key"}})
+	if err != nil || !result.IsError {
+		t.Fatalf("call = %v, %v, want a tool error", resultText(result), err)
+	}
+	body := parseToolError(resultText(result))
+	if body.Code != codeOutOfCredits || body.UpstreamStatus != http.StatusPaymentRequired || body.Retryable {
+		t.Errorf("error = %+v, want out_of_credits from upstream 402", body)
+	}
+}
@@ -0,0 +1,246 @@
+package mcpserver
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// CATALOG SUBCOMMAND
+// ============================================================================
+//
+// interzoid-mcp-server catalog [--format markdown|json|openapi] [--summary]
+//
+// Renders the tool catalog built by registerAllTools, so documentation is
+// generated from the registrations rather than maintained by hand:
+//
+//   - markdown: a table per category for the wiki, or with --summary the
+//     category table at the top of the README
+//   - json:     the catalog entries, as served by the interzoid://catalog
+//     resource
+//   - openapi:  an OpenAPI 3.1 document describing the upstream Interzoid
+//     endpoints, with query parameters under their API names
+// ============================================================================
+
+func runCatalogCommand(args []string) int {
+	fs := flag.NewFlagSet("catalog", flag.ContinueOnError)
+	format := fs.String("format", "markdown", "Output format: markdown, json or openapi")
+	summary := fs.Bool("summary", false, "With --format markdown, print only the per-category summary table")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	_, catalog := buildServer(toolFilter{}, nil)
+	entries := catalog.list()
+
+	var err error
+	switch *format {
+	case "markdown":
+		if *summary {
+			err = writeCatalogSummary(os.Stdout, entries)
+		} else {
+			err = writeCatalogMarkdown(os.Stdout, entries)
+		}
+	case "json":
+		err = writeJSON(os.Stdout, entries)
+	case "openapi":
+		err = writeJSON(os.Stdout, openAPIDocument(entries))
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format: %s (use 'markdown', 'json' or 'openapi')\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// ---------------------------------------------------------------------------
+// Markdown
+// ---------------------------------------------------------------------------
+
+// writeCatalogSummary writes one table row per category listing its tools
+// and price.
+func writeCatalogSummary(w io.Writer, entries []catalogEntry) error {
+	fmt.Fprintf(w, "### Available APIs (%d Tools)\n\n", len(entries))
+	fmt.Fprintln(w, "| Category | Tools | Price (USDC) |")
+	fmt.Fprintln(w, "|---|---|---|")
+	for _, category := range apiCategories {
+		var titles, prices []string
+		premium := false
+		for _, e := range entries {
+			if e.Category != category {
+				continue
+			}
+			titles = append(titles, e.Title)
+			if price := formatUSD(e.PriceUSD) + "/call"; !contains(prices, price) {
+				prices = append(prices, price)
+			}
+			premium = premium || e.PriceTier == premiumTier.name
+		}
+		if len(titles) == 0 {
+			continue
+		}
+		sort.Strings(titles)
+
+		label := fmt.Sprintf("**%s** — %s", category, categoryDescriptions[category])
+		if premium {
+			label += " (Premium)"
+		}
+		fmt.Fprintf(w, "| %s | %s | %s |\n", label, strings.Join(titles, ", "), strings.Join(prices, " or "))
+	}
+	return nil
+}
+
+// writeCatalogMarkdown writes a table of tools for each category.
+func writeCatalogMarkdown(w io.Writer, entries []catalogEntry) error {
+	fmt.Fprintf(w, "# Interzoid MCP Tools (%d)\n", len(entries))
+	for _, category := range apiCategories {
+		header := false
+		for _, e := range entries {
+			if e.Category != category {
+				continue
+			}
+			if !header {
+				fmt.Fprintf(w, "\n## %s\n\n", category)
+				fmt.Fprintln(w, "| Tool | Endpoint | Parameters | Price | Description |")
+				fmt.Fprintln(w, "|---|---|---|---|---|")
+				header = true
+			}
+
+			var params []string
+			for _, p := range e.Parameters {
+				param := "`" + p.Name + "`"
+				if p.APIName != p.Name {
+					param += " (as `" + p.APIName + "`)"
+				}
+				if p.Required {
+					param += " required"
+				}
+				params = append(params, param)
+			}
+			fmt.Fprintf(w, "| `%s`<br>%s | `%s` | %s | %s (%s) | %s |\n",
+				e.Name, markdownCell(e.Title), e.Endpoint, strings.Join(params, "<br>"),
+				formatUSD(e.PriceUSD), e.PriceTier, markdownCell(e.Description))
+		}
+	}
+	return nil
+}
+
+// markdownCell escapes text for a Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// formatUSD formats a per-call price in dollars.
+func formatUSD(usd float64) string {
+	return fmt.Sprintf("$%g", usd)
+}
+
+func contains(values []string, v string) bool {
+	for _, existing := range values {
+		if existing == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------------------------------------------------------------------
+// OpenAPI
+// ---------------------------------------------------------------------------
+
+// openAPIDocument describes the upstream Interzoid endpoints behind the
+// catalog's tools. Each operation's ID is the tool name, and its price is
+// given in the x-interzoid-price extension.
+func openAPIDocument(entries []catalogEntry) map[string]interface{} {
+	var tags []map[string]interface{}
+	for _, category := range apiCategories {
+		tags = append(tags, map[string]interface{}{
+			"name":        category,
+			"description": categoryDescriptions[category],
+		})
+	}
+
+	paths := make(map[string]interface{}, len(entries))
+	for _, e := range entries {
+		var params []map[string]interface{}
+		for _, p := range e.Parameters {
+			schema := map[string]interface{}{"type": "string"}
+			if len(p.Enum) > 0 {
+				schema["enum"] = p.Enum
+			}
+			params = append(params, map[string]interface{}{
+				"name":        p.APIName,
+				"in":          "query",
+				"required":    p.Required,
+				"description": p.Description,
+				"schema":      schema,
+			})
+		}
+
+		paths[e.Endpoint] = map[string]interface{}{
+			"get": map[string]interface{}{
+				"operationId": e.Name,
+				"summary":     e.Title,
+				"description": e.Description,
+				"tags":        []string{e.Category},
+				"parameters":  params,
+				"security": []map[string]interface{}{
+					{"apiKey": []string{}},
+					{}, // x402: no key, pay per call
+				},
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": "Lookup result",
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{"type": "object"},
+							},
+						},
+					},
+					"402": map[string]interface{}{
+						"description": "Payment required: no API key was sent, and the body lists the x402 payment requirements",
+					},
+				},
+				"x-interzoid-price": priceMetadata(tierForEndpoint(e.Endpoint)),
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":       "Interzoid APIs",
+			"version":     serverVersion,
+			"description": "The Interzoid data quality, matching, enrichment and standardization APIs exposed as tools by the Interzoid MCP server. Calls are paid with account credits when an API key is sent, or per call with x402 USDC micropayments otherwise.",
+		},
+		"servers": []map[string]interface{}{{"url": interzoidBaseURL}},
+		"tags":    tags,
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"apiKey": map[string]interface{}{
+					"type": "apiKey",
+					"in":   "header",
+					"name": "x-api-key",
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package mcpserver
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// catalogForTest registers every tool and returns the catalog entries.
+func catalogForTest(t *testing.T) []catalogEntry {
+	t.Helper()
+	_, catalog := buildServer(toolFilter{}, nil)
+	entries := catalog.list()
+	if len(entries) == 0 {
+		t.Fatal("empty catalog")
+	}
+	return entries
+}
+
+// The summary table in the README is generated by the catalog command and
+// must be regenerated when the tools change.
+func TestREADMESummaryIsCurrent(t *testing.T) {
+	var b strings.Builder
+	if err := writeCatalogSummary(&b, catalogForTest(t)); err != nil {
+		t.Fatal(err)
+	}
+	readme, err := os.ReadFile(filepath.Join("..", "..", "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(readme), b.String()) {
+		t.Errorf("README.md summary is stale; regenerate it with `catalog --format markdown --summary`:\n%s", b.String())
+	}
+}
+
+func TestCatalogMarkdown(t *testing.T) {
+	entries := catalogForTest(t)
+	var b strings.Builder
+	if err := writeCatalogMarkdown(&b, entries); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, e := range entries {
+		if !strings.Contains(out, "| `"+e.Name+"`<br>") {
+			t.Errorf("%s missing from the Markdown catalog", e.Name)
+		}
+	}
+	if got := markdownCell("a | b\nc"); got != `a \| b c` {
+		t.Errorf("markdownCell = %q", got)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	entries := catalogForTest(t)
+
+	// Round-trip through JSON to check the document as clients see it
+	data, err := json.Marshal(openAPIDocument(entries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]struct {
+			Get struct {
+				OperationID string `json:"operationId"`
+				Tags        []string
+				Parameters  []struct {
+					Name     string
+					In       string
+					Required bool
+					Schema   struct {
+						Enum []string
+					}
+				}
+				Price struct {
+					Tier            string
+					PriceAtomicUSDC int64
+				} `json:"x-interzoid-price"`
+			}
+		}
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.1.0" || len(doc.Paths) != len(entries) {
+		t.Fatalf("openapi %q with %d paths for %d entries", doc.OpenAPI, len(doc.Paths), len(entries))
+	}
+
+	for _, e := range entries {
+		op := doc.Paths[e.Endpoint].Get
+		if op.OperationID != e.Name || len(op.Tags) != 1 || op.Tags[0] != e.Category {
+			t.Errorf("%s: operation %q tags %v", e.Endpoint, op.OperationID, op.Tags)
+		}
+		if tier := tierForEndpoint(e.Endpoint); op.Price.Tier != tier.name || op.Price.PriceAtomicUSDC != tier.atomicUSDC {
+			t.Errorf("%s: price %+v, want tier %s", e.Endpoint, op.Price, tier.name)
+		}
+		for i, p := range op.Parameters {
+			want := e.Parameters[i]
+			if p.Name != want.APIName || p.In != "query" || p.Required != want.Required {
+				t.Errorf("%s: parameter %+v, want %s", e.Endpoint, p, want.APIName)
+			}
+			if len(p.Schema.Enum) != len(want.Enum) {
+				t.Errorf("%s: %s schema %+v", e.Endpoint, p.Name, p.Schema)
+			}
+		}
+	}
+
+	// The algorithm variants are part of the schema
+	op := doc.Paths["/getcompanymatchadvanced"].Get
+	found := false
+	for _, p := range op.Parameters {
+		found = found || p.Name == "algorithm" && len(p.Schema.Enum) > 0
+	}
+	if !found {
+		t.Errorf("/getcompanymatchadvanced algorithm has no enum: %+v", op.Parameters)
+	}
+}
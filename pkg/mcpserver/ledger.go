@@ -0,0 +1,266 @@
+package mcpserver
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+
+	_ "modernc.org/sqlite"
+)
+
+// usageLedger records every upstream Interzoid call. It is nil (disabled)
+// when the -ledger flag is empty or the database cannot be opened.
+var usageLedger *ledger
+
+const ledgerSchema = `
+CREATE TABLE IF NOT EXISTS usage (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	ts           TEXT    NOT NULL,
+	tool         TEXT    NOT NULL,
+	endpoint     TEXT    NOT NULL,
+	caller       TEXT    NOT NULL,
+	tag          TEXT    NOT NULL DEFAULT '',
+	status       TEXT    NOT NULL,
+	tier         TEXT    NOT NULL,
+	price_atomic INTEGER NOT NULL,
+	payment      TEXT    NOT NULL,
+	duration_ms  INTEGER NOT NULL,
+	error        TEXT    NOT NULL DEFAULT '',
+	cached       INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS usage_ts ON usage (ts);
+`
+
+// Ledger statuses for an upstream call.
+const (
+	usageOK              = "ok"
+	usagePaymentRequired = "payment_required"
+	usageError           = "error"
+)
+
+// ledger is an append-only SQLite log of upstream calls used for usage
+// reporting and chargeback.
+type ledger struct {
+	db *sql.DB
+}
+
+// ledgerEntry is one upstream call.
+type ledgerEntry struct {
+	time     time.Time
+	tool     string
+	endpoint string
+	caller   string
+	tag      string
+	status   string
+	tier     priceTier
+	payment  string // "key" or "x402"
+	duration time.Duration
+	err      string
+	cached   bool // answered from the server's response cache, at no charge
+}
+
+// openLedger opens (creating if necessary) the SQLite ledger at path.
+func openLedger(path string) (*ledger, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create ledger directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ledger: %w", err)
+	}
+	// SQLite allows a single writer; serializing here avoids SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(ledgerSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize ledger: %w", err)
+	}
+
+	return &ledger{db: db}, nil
+}
+
+func (l *ledger) close() error {
+	if l == nil {
+		return nil
+	}
+	return l.db.Close()
+}
+
+// append writes an entry to the ledger. Failures are logged rather than
+// returned: a broken ledger must never fail the tool call itself.
+func (l *ledger) append(e ledgerEntry) {
+	if l == nil {
+		return
+	}
+
+	var price int64
+	if e.status == usageOK && !e.cached {
+		price = e.tier.atomicUSDC
+	}
+
+	_, err := l.db.Exec(
+		`INSERT INTO usage (ts, tool, endpoint, caller, tag, status, tier, price_atomic, payment, duration_ms, error, cached)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.time.UTC().Format(time.RFC3339), e.tool, e.endpoint, e.caller, e.tag, e.status,
+		e.tier.name, price, e.payment, e.duration.Milliseconds(), e.err, e.cached,
+	)
+	if err != nil {
+		log.Printf("usage ledger: failed to record call to %s: %v", e.endpoint, err)
+	}
+}
+
+// recordUsage appends the outcome of an upstream call made by genericHandler,
+// or of a call answered from the response cache when cached is set. Calls
+// short-circuited by an open circuit breaker never reached Interzoid and are
+// not recorded.
+func recordUsage(ctx context.Context, request mcp.CallToolRequest, endpoint, apiKey string, start time.Time, result map[string]interface{}, callErr error, cached bool) {
+	if usageLedger == nil {
+		return
+	}
+
+	var open *circuitOpenError
+	if errors.As(callErr, &open) {
+		return
+	}
+
+	e := ledgerEntry{
+		time:     start,
+		tool:     request.Params.Name,
+		endpoint: endpoint,
+		caller:   callerID(ctx, request),
+		tag:      usageTag(request),
+		status:   usageOK,
+		tier:     tierForEndpoint(endpoint),
+		payment:  "key",
+		duration: time.Since(start),
+		cached:   cached,
+	}
+	if apiKey == "" {
+		e.payment = "x402"
+	}
+	switch {
+	case callErr != nil:
+		e.status = usageError
+		e.err = callErr.Error()
+	case result["status"] == "payment_required":
+		e.status = usagePaymentRequired
+	}
+
+	usageLedger.append(e)
+}
+
+// usageGroupings maps a report grouping to the SQL expression it groups by.
+var usageGroupings = map[string]string{
+	"day":    "substr(ts, 1, 10)",
+	"tool":   "tool",
+	"caller": "caller",
+	"tag":    "tag",
+}
+
+// usageFilter restricts a usage report. Zero values mean unrestricted.
+type usageFilter struct {
+	since  time.Time // inclusive
+	until  time.Time // exclusive
+	caller string
+}
+
+// usageRow is one line of a usage report.
+type usageRow struct {
+	Group           string  `json:"group"`
+	Calls           int64   `json:"calls"`
+	Succeeded       int64   `json:"succeeded"`
+	PaymentRequired int64   `json:"paymentRequired"`
+	Failed          int64   `json:"failed"`
+	Cached          int64   `json:"cached"`
+	SpendAtomicUSDC int64   `json:"spendAtomicUSDC"`
+	SpendUSD        float64 `json:"spendUSD"`
+}
+
+// summarize aggregates the ledger by the given grouping (day, tool, caller
+// or tag).
+func (l *ledger) summarize(groupBy string, f usageFilter) ([]usageRow, error) {
+	expr, ok := usageGroupings[groupBy]
+	if !ok {
+		return nil, newToolError(codeInvalidInput, "invalid grouping %q (use day, tool, caller or tag)", groupBy)
+	}
+
+	query := `SELECT ` + expr + `, COUNT(*),
+		SUM(status = 'ok'), SUM(status = 'payment_required'), SUM(status = 'error'),
+		SUM(cached), SUM(price_atomic)
+		FROM usage WHERE 1 = 1`
+	var args []interface{}
+	if !f.since.IsZero() {
+		query += ` AND ts >= ?`
+		args = append(args, f.since.UTC().Format(time.RFC3339))
+	}
+	if !f.until.IsZero() {
+		query += ` AND ts < ?`
+		args = append(args, f.until.UTC().Format(time.RFC3339))
+	}
+	if f.caller != "" {
+		query += ` AND caller = ?`
+		args = append(args, f.caller)
+	}
+	query += ` GROUP BY 1 ORDER BY 1`
+
+	rows, err := l.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger: %w", err)
+	}
+	defer rows.Close()
+
+	report := []usageRow{}
+	for rows.Next() {
+		var r usageRow
+		if err := rows.Scan(&r.Group, &r.Calls, &r.Succeeded, &r.PaymentRequired, &r.Failed, &r.Cached, &r.SpendAtomicUSDC); err != nil {
+			return nil, fmt.Errorf("failed to read ledger: %w", err)
+		}
+		r.SpendUSD = float64(r.SpendAtomicUSDC) / 1e6
+		report = append(report, r)
+	}
+	return report, rows.Err()
+}
+
+// writeUsageCSV writes a usage report as CSV for chargeback spreadsheets.
+func writeUsageCSV(w io.Writer, groupBy string, report []usageRow) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{groupBy, "calls", "succeeded", "payment_required", "failed", "cached", "spend_atomic_usdc", "spend_usd"})
+	for _, r := range report {
+		cw.Write([]string{
+			r.Group,
+			strconv.FormatInt(r.Calls, 10),
+			strconv.FormatInt(r.Succeeded, 10),
+			strconv.FormatInt(r.PaymentRequired, 10),
+			strconv.FormatInt(r.Failed, 10),
+			strconv.FormatInt(r.Cached, 10),
+			strconv.FormatInt(r.SpendAtomicUSDC, 10),
+			strconv.FormatFloat(r.SpendUSD, 'f', 4, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// parseReportDate parses a YYYY-MM-DD report bound. An empty string yields
+// the zero time (unbounded).
+func parseReportDate(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date in YYYY-MM-DD format, got %q", name, value)
+	}
+	return t, nil
+}
@@ -0,0 +1,164 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// testLedger opens a ledger in a temporary directory and makes it the
+// server's ledger for the rest of the test.
+func testLedger(t *testing.T) *ledger {
+	t.Helper()
+	l, err := openLedger(filepath.Join(t.TempDir(), "usage.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	usageLedger = l
+	t.Cleanup(func() {
+		usageLedger = nil
+		l.close()
+	})
+	return l
+}
+
+func TestLedgerSummarize(t *testing.T) {
+	l := testLedger(t)
+	day := time.Date(2026, 3, 14, 9, 0, 0, 0, time.UTC)
+	entries := []ledgerEntry{
+		{time: day, tool: "interzoid_gender", caller: "key:a", status: usageOK, tier: standardTier, payment: "key"},
+		{time: day, tool: "interzoid_gender", caller: "key:a", status: usageOK, tier: standardTier, payment: "key", cached: true},
+		{time: day, tool: "interzoid_business_info", caller: "key:b", status: usageOK, tier: premiumTier, payment: "key"},
+		{time: day.Add(24 * time.Hour), tool: "interzoid_business_info", caller: "ip:203.0.113.7", status: usagePaymentRequired, tier: premiumTier, payment: "x402"},
+		{time: day.Add(24 * time.Hour), tool: "interzoid_gender", caller: "key:a", status: usageError, tier: standardTier, payment: "key", err: "status 500"},
+	}
+	for _, e := range entries {
+		e.endpoint = "/x"
+		l.append(e)
+	}
+
+	byTool, err := l.summarize("tool", usageFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []usageRow{
+		{Group: "interzoid_business_info", Calls: 2, Succeeded: 1, PaymentRequired: 1, SpendAtomicUSDC: 312500, SpendUSD: 0.3125},
+		// Cached and failed calls cost nothing
+		{Group: "interzoid_gender", Calls: 3, Succeeded: 2, Failed: 1, Cached: 1, SpendAtomicUSDC: 12500, SpendUSD: 0.0125},
+	}
+	if len(byTool) != len(want) {
+		t.Fatalf("rows = %+v, want %+v", byTool, want)
+	}
+	for i := range want {
+		if byTool[i] != want[i] {
+			t.Errorf("row %d = %+v, want %+v", i, byTool[i], want[i])
+		}
+	}
+
+	// Date bounds are inclusive days, and the caller filter is exact
+	f, err := reportFilter("2026-03-14", "2026-03-14")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.caller = "key:a"
+	byDay, err := l.summarize("day", f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byDay) != 1 || byDay[0].Group != "2026-03-14" || byDay[0].Calls != 2 {
+		t.Errorf("filtered report = %+v, want 2 calls on 2026-03-14", byDay)
+	}
+
+	if _, err := l.summarize("endpoint", usageFilter{}); err == nil {
+		t.Error("unknown grouping accepted")
+	}
+}
+
+func TestWriteUsageCSV(t *testing.T) {
+	var buf bytes.Buffer
+	report := []usageRow{{Group: "2026-03-14", Calls: 3, Succeeded: 2, Failed: 1, Cached: 1, SpendAtomicUSDC: 12500, SpendUSD: 0.0125}}
+	if err := writeUsageCSV(&buf, "day", report); err != nil {
+		t.Fatal(err)
+	}
+	want := "day,calls,succeeded,payment_required,failed,cached,spend_atomic_usdc,spend_usd\n2026-03-14,3,2,0,1,1,12500,0.0125\n"
+	if buf.String() != want {
+		t.Errorf("CSV = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestRecordUsage(t *testing.T) {
+	l := testLedger(t)
+	var request mcp.CallToolRequest
+	request.Params.Name = "interzoid_gender"
+	request.Header = http.Header{"X-Interzoid-Tag": {"marketing"}}
+	start := time.Now()
+
+	recordUsage(context.Background(), request, "/getgender", "", start, map[string]interface{}{"status": "payment_required"}, nil, false)
+	recordUsage(context.Background(), request, "/getgender", "key", start, nil, errors.New("status 502"), false)
+	// Calls refused by an open circuit never reached Interzoid
+	recordUsage(context.Background(), request, "/getgender", "key", start, nil, &circuitOpenError{endpoint: "/getgender"}, false)
+
+	report, err := l.summarize("tag", usageFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report) != 1 || report[0].Group != "marketing" || report[0].Calls != 2 || report[0].PaymentRequired != 1 || report[0].Failed != 1 {
+		t.Errorf("report = %+v, want 2 marketing calls, one 402 and one failure", report)
+	}
+}
+
+func TestUsageReportTool(t *testing.T) {
+	l := testLedger(t)
+	now := time.Now()
+	l.append(ledgerEntry{time: now, tool: "interzoid_gender", caller: keyID("tenant-a"), status: usageOK, tier: standardTier, payment: "key"})
+	l.append(ledgerEntry{time: now, tool: "interzoid_gender", caller: keyID("tenant-b"), status: usageOK, tier: standardTier, payment: "key"})
+	l.append(ledgerEntry{time: now, tool: "interzoid_gender", caller: "ip:203.0.113.7", status: usageOK, tier: standardTier, payment: "x402"})
+
+	remote := context.WithValue(context.Background(), remoteAddrKey, "203.0.113.7")
+	args := map[string]interface{}{"group_by": "caller", "format": "csv"}
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		header    http.Header
+		wantError bool
+		want      []string // callers in the report
+	}{
+		{"local operator sees everyone", context.Background(), nil, false, []string{keyID("tenant-a"), keyID("tenant-b"), "ip:203.0.113.7"}},
+		{"remote caller sees its own key", remote, http.Header{"Authorization": {"Bearer tenant-a"}}, false, []string{keyID("tenant-a")}},
+		{"anonymous remote caller is refused", remote, nil, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := callToolHandler(tt.ctx, t, "interzoid_usage_report", args, tt.header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := resultText(result)
+			if result.IsError != tt.wantError {
+				t.Fatalf("IsError = %v: %s", result.IsError, text)
+			}
+			lines := strings.Split(strings.TrimSpace(text), "\n")
+			if !tt.wantError && len(lines)-1 != len(tt.want) {
+				t.Errorf("report rows = %d, want %d:\n%s", len(lines)-1, len(tt.want), text)
+			}
+			for _, caller := range tt.want {
+				if !strings.Contains(text, caller) {
+					t.Errorf("report has no row for %s:\n%s", caller, text)
+				}
+			}
+		})
+	}
+}
+
+func TestUsageCommandNeedsLedger(t *testing.T) {
+	if code := runUsageCommand(nil); code != 2 {
+		t.Errorf("exit code = %d, want 2", code)
+	}
+}
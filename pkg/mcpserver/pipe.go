@@ -0,0 +1,531 @@
+package mcpserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============================================================================
+// PIPE SUBCOMMAND
+// ============================================================================
+//
+// interzoid-mcp-server pipe --tool <tool> --column <name> < in.csv > out.csv
+//
+// Streams CSV or NDJSON rows from stdin through one tool and writes each row
+// to stdout in input order with the result fields appended. Calls run with
+// bounded concurrency through the server, as MCP tool calls do, so
+// validation, rate limiting, the circuit breaker, the credit guard, the
+// response cache and the usage ledger all apply. The run is one session, so
+// the session budget caps what it spends. Progress can be checkpointed so an
+// interrupted run resumes where it stopped.
+// ============================================================================
+
+// pipeWindow is how many rows may be read ahead of the last row written, per
+// concurrent call.
+const pipeWindow = 16
+
+// pipeRow is one input row and, once called, its result.
+type pipeRow struct {
+	index  int
+	record []string               // CSV fields
+	object map[string]interface{} // NDJSON object, or the bound CSV fields by column
+	result map[string]interface{}
+	err    string
+}
+
+// pipeCheckpoint records how many rows have been written, and the result
+// columns of CSV output, so a run can resume. RawResult records that the
+// CSV output has a single column holding each result as JSON.
+type pipeCheckpoint struct {
+	Rows      int      `json:"rows"`
+	Columns   []string `json:"columns,omitempty"`
+	RawResult bool     `json:"rawResult,omitempty"`
+}
+
+// columnFlags collects repeated --column flags, each either "column" (for a
+// tool with one required parameter) or "parameter=column".
+type columnFlags []string
+
+func (c *columnFlags) String() string { return strings.Join(*c, ",") }
+func (c *columnFlags) Set(v string) error {
+	*c = append(*c, v)
+	return nil
+}
+
+func runPipeCommand(args []string) int {
+	fs := flag.NewFlagSet("pipe", flag.ContinueOnError)
+	toolName := fs.String("tool", "", "Tool to call for each row")
+	var columns columnFlags
+	fs.Var(&columns, "column", "Input column for the tool's parameter, as column or parameter=column (repeat for tools with several parameters)")
+	constArgs := argFlags{}
+	fs.Var(constArgs, "arg", "Argument passed unchanged on every call, as name=value (e.g. algorithm=ai-plus)")
+	format := fs.String("format", "auto", "Input and output format: csv, ndjson or auto (detect from the first byte)")
+	concurrency := fs.Int("concurrency", batchConcurrency, "Calls in flight at once")
+	checkpointPath := fs.String("checkpoint", "", "File recording progress; rerun with the same file (appending to the output) to resume")
+	cfg, err := loadConfigFlag(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	addCallFlags(fs, cfg)
+	addBudgetFlag(fs, cfg)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: interzoid-mcp-server pipe --tool <tool> --column <name> [flags] < input > output")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *toolName == "" || len(columns) == 0 || *concurrency < 1 {
+		fs.Usage()
+		return 2
+	}
+	if err := cfg.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer cfg.apply()()
+
+	s, _ := buildServer(cfg.Tools, nil)
+	tool := s.GetTool(*toolName)
+	if tool == nil {
+		fmt.Fprintf(os.Stderr, "Unknown tool: %s\n", *toolName)
+		return 2
+	}
+	bindings, err := bindColumns(tool.Tool, columns)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var checkpoint pipeCheckpoint
+	if *checkpointPath != "" {
+		if checkpoint, err = readCheckpoint(*checkpointPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	if *format == "auto" {
+		*format = "csv"
+		if first, err := in.Peek(1); err == nil && first[0] == '{' {
+			*format = "ndjson"
+		}
+	}
+
+	var p *pipeline
+	switch *format {
+	case "csv":
+		p, err = newCSVPipeline(in, os.Stdout, bindings, checkpoint)
+	case "ndjson":
+		p, err = newNDJSONPipeline(in, os.Stdout, bindings, checkpoint)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format: %s (use 'csv', 'ndjson' or 'auto')\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx := withRunSession(context.Background(), s)
+	p.call = func(row *pipeRow) {
+		callPipeRow(ctx, s, *toolName, bindings, constArgs.arguments(), row)
+	}
+	p.checkpointPath = *checkpointPath
+	if err := p.run(*concurrency); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Fprintf(os.Stderr, "%d rows written (%d failed)\n", p.checkpoint.Rows, p.failed)
+	if p.failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// bindColumns maps the --column flags to tool parameters.
+func bindColumns(tool mcp.Tool, columns []string) (map[string]string, error) {
+	bindings := make(map[string]string, len(columns))
+	for _, c := range columns {
+		param, column, ok := strings.Cut(c, "=")
+		if !ok {
+			if len(tool.InputSchema.Required) != 1 {
+				return nil, fmt.Errorf("%s takes parameters %s; use --column parameter=column for each",
+					tool.Name, strings.Join(tool.InputSchema.Required, ", "))
+			}
+			param, column = tool.InputSchema.Required[0], c
+		}
+		if _, known := tool.InputSchema.Properties[param]; !known {
+			return nil, fmt.Errorf("%s has no parameter %q", tool.Name, param)
+		}
+		bindings[param] = column
+	}
+	return bindings, nil
+}
+
+// callPipeRow calls the tool for one row in the run's session ctx, waiting
+// out inbound rate limits rather than failing the row.
+func callPipeRow(ctx context.Context, s *server.MCPServer, toolName string, bindings map[string]string, constArgs map[string]interface{}, row *pipeRow) {
+	args := make(map[string]interface{}, len(bindings)+len(constArgs))
+	for name, v := range constArgs {
+		args[name] = v
+	}
+	for param, column := range bindings {
+		args[param] = row.value(column)
+	}
+
+	for {
+		result, err := callTool(ctx, s, toolName, args)
+		if err != nil {
+			row.err = err.Error()
+			return
+		}
+
+		text := resultText(result)
+		if result.IsError {
+			body := parseToolError(text)
+			// The server's own limit (no upstream status) always clears
+			if body.Code == codeRateLimited && body.UpstreamStatus == 0 {
+				time.Sleep(time.Duration(max(body.RetryAfterSeconds, 1)) * time.Second)
+				continue
+			}
+			row.err = fmt.Sprintf("%s: %s", body.Code, body.Error)
+			return
+		}
+		if err := json.Unmarshal([]byte(text), &row.result); err != nil {
+			row.err = "unexpected result: " + text
+		}
+		return
+	}
+}
+
+// value returns the input value of a column.
+func (row *pipeRow) value(column string) interface{} {
+	return row.object[column]
+}
+
+// readCheckpoint reads a checkpoint file; a missing file means a fresh run.
+func readCheckpoint(path string) (pipeCheckpoint, error) {
+	var cp pipeCheckpoint
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return cp, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return cp, fmt.Errorf("invalid checkpoint %s: %w", path, err)
+	}
+	return cp, nil
+}
+
+// writeCheckpoint replaces the checkpoint file atomically.
+func writeCheckpoint(path string, cp pipeCheckpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ---------------------------------------------------------------------------
+// Pipeline
+// ---------------------------------------------------------------------------
+
+// pipeline reads rows, calls the tool for each with bounded concurrency, and
+// writes them back in input order.
+type pipeline struct {
+	read  func() (*pipeRow, error) // returns io.EOF at the end of input
+	write func(*pipeRow) error
+	flush func() error
+	call  func(*pipeRow)
+
+	// needColumns reports that CSV result columns are not yet known;
+	// setColumns fixes them from a successful result, and setRawColumns
+	// fixes a single column for whole results when rows must be written
+	// before any result is known
+	needColumns   func() bool
+	setColumns    func(result map[string]interface{})
+	setRawColumns func()
+
+	checkpoint     pipeCheckpoint
+	checkpointPath string
+	failed         int
+}
+
+func (p *pipeline) run(concurrency int) error {
+	// Skip rows written by an earlier run
+	start := p.checkpoint.Rows
+	for i := 0; i < start; i++ {
+		if _, err := p.read(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+
+	window := concurrency * pipeWindow
+	sem := make(chan struct{}, concurrency)
+	done := make(chan *pipeRow)
+	pending := make(map[int]*pipeRow)
+	next, dispatched, inFlight := 0, 0, 0
+	eof := false
+
+	for !eof || inFlight > 0 || len(pending) > 0 {
+		// Read ahead while there is room in the window
+		for !eof && dispatched-next < window {
+			row, err := p.read()
+			if err == io.EOF {
+				eof = true
+				break
+			}
+			if err != nil {
+				return err
+			}
+			row.index = dispatched
+			dispatched++
+			inFlight++
+			go func(row *pipeRow) {
+				sem <- struct{}{}
+				p.call(row)
+				<-sem
+				done <- row
+			}(row)
+		}
+
+		if inFlight > 0 {
+			row := <-done
+			inFlight--
+			pending[row.index] = row
+		}
+
+		// CSV result columns come from the first successful result. When the
+		// window fills with failed rows first, they are written with a
+		// single column for later results as JSON, so memory stays bounded
+		// and the checkpoint advances; when no row at all succeeded, with
+		// just the error column.
+		if p.needColumns() {
+			first := -1
+			for i, row := range pending {
+				if row.err == "" && (first < 0 || i < first) {
+					first = i
+				}
+			}
+			switch {
+			case first >= 0:
+				p.setColumns(pending[first].result)
+			case eof && inFlight == 0:
+				p.setColumns(nil)
+			case inFlight == 0:
+				p.setRawColumns()
+			default:
+				continue
+			}
+		}
+
+		if _, ok := pending[next]; !ok {
+			continue
+		}
+		for row, ok := pending[next]; ok; row, ok = pending[next] {
+			if err := p.write(row); err != nil {
+				return err
+			}
+			if row.err != "" {
+				p.failed++
+			}
+			delete(pending, next)
+			next++
+		}
+		if err := p.flush(); err != nil {
+			return err
+		}
+		p.checkpoint.Rows = start + next
+		if p.checkpointPath != "" {
+			if err := writeCheckpoint(p.checkpointPath, p.checkpoint); err != nil {
+				return fmt.Errorf("failed to write checkpoint: %w", err)
+			}
+		}
+	}
+
+	// Empty input still gets a header
+	if p.needColumns() {
+		p.setColumns(nil)
+	}
+	return p.flush()
+}
+
+// ---------------------------------------------------------------------------
+// CSV
+// ---------------------------------------------------------------------------
+
+func newCSVPipeline(in io.Reader, out io.Writer, bindings map[string]string, cp pipeCheckpoint) (*pipeline, error) {
+	r := csv.NewReader(in)
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	index := make(map[string]int, len(header))
+	for i, h := range header {
+		index[h] = i
+	}
+	for _, column := range bindings {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("input has no column %q (columns: %s)", column, strings.Join(header, ", "))
+		}
+	}
+
+	w := csv.NewWriter(out)
+	columns, raw := cp.Columns, cp.RawResult
+	known := cp.Rows > 0
+
+	p := &pipeline{checkpoint: cp}
+	p.read = func() (*pipeRow, error) {
+		record, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+		return &pipeRow{record: record, object: csvObject(header, index, record, bindings)}, nil
+	}
+	p.needColumns = func() bool { return !known }
+	p.setColumns = func(result map[string]interface{}) {
+		columns = resultColumns(header, result)
+		known = true
+		p.checkpoint.Columns = columns
+		w.Write(append(append(append([]string(nil), header...), columns...), "error"))
+	}
+	p.setRawColumns = func() {
+		p.setColumns(map[string]interface{}{"result": nil})
+		columns, raw = nil, true
+		p.checkpoint.Columns, p.checkpoint.RawResult = nil, true
+	}
+	p.write = func(row *pipeRow) error {
+		out := append([]string(nil), row.record...)
+		if raw {
+			cell := ""
+			if row.result != nil {
+				cell = tableCell(row.result)
+			}
+			out = append(out, cell)
+		}
+		for _, c := range columns {
+			out = append(out, tableCell(row.result[resultKey(c, header)]))
+		}
+		return w.Write(append(out, row.err))
+	}
+	p.flush = func() error {
+		w.Flush()
+		return w.Error()
+	}
+	return p, nil
+}
+
+// csvObject exposes the bound columns of a CSV record by name.
+func csvObject(header []string, index map[string]int, record []string, bindings map[string]string) map[string]interface{} {
+	obj := make(map[string]interface{}, len(bindings))
+	for _, column := range bindings {
+		if i := index[column]; i < len(record) {
+			obj[column] = record[i]
+		}
+	}
+	return obj
+}
+
+// resultColumns returns the output columns for a result's fields, prefixed
+// with "result_" where they collide with an input column.
+func resultColumns(header []string, result map[string]interface{}) []string {
+	keys := make([]string, 0, len(result))
+	for k := range result {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	taken := make(map[string]bool, len(header))
+	for _, h := range header {
+		taken[h] = true
+	}
+	taken["error"] = true
+	for i, k := range keys {
+		if taken[k] {
+			keys[i] = "result_" + k
+		}
+	}
+	return keys
+}
+
+// resultKey maps an output column back to its result field.
+func resultKey(column string, header []string) string {
+	if k, ok := strings.CutPrefix(column, "result_"); ok {
+		for _, h := range append(header, "error") {
+			if h == k {
+				return k
+			}
+		}
+	}
+	return column
+}
+
+// ---------------------------------------------------------------------------
+// NDJSON
+// ---------------------------------------------------------------------------
+
+func newNDJSONPipeline(in io.Reader, out io.Writer, bindings map[string]string, cp pipeCheckpoint) (*pipeline, error) {
+	dec := json.NewDecoder(in)
+	dec.UseNumber()
+	bw := bufio.NewWriter(out)
+	enc := json.NewEncoder(bw)
+
+	p := &pipeline{checkpoint: cp}
+	p.read = func() (*pipeRow, error) {
+		var obj map[string]interface{}
+		if err := dec.Decode(&obj); err != nil {
+			if err == io.EOF {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("invalid NDJSON input: %w", err)
+		}
+		return &pipeRow{object: obj}, nil
+	}
+	p.needColumns = func() bool { return false }
+	p.setColumns = func(map[string]interface{}) {}
+	p.setRawColumns = func() {}
+	p.write = func(row *pipeRow) error {
+		out := make(map[string]interface{}, len(row.object)+len(row.result)+1)
+		for k, v := range row.object {
+			out[k] = v
+		}
+		for k, v := range row.result {
+			if _, taken := row.object[k]; taken {
+				k = "result_" + k
+			}
+			out[k] = v
+		}
+		if row.err != "" {
+			out["error"] = row.err
+		}
+		return enc.Encode(out)
+	}
+	p.flush = bw.Flush
+	return p, nil
+}
@@ -0,0 +1,274 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// upperCall is a pipeline call that fails rows whose name starts with "bad"
+// and otherwise returns the name upper-cased.
+func upperCall(row *pipeRow) {
+	name, _ := row.value("name").(string)
+	if strings.HasPrefix(name, "bad") {
+		row.err = "invalid name"
+		return
+	}
+	row.result = map[string]interface{}{"Upper": strings.ToUpper(name), "name": "collides"}
+}
+
+func runCSV(t *testing.T, input string, cp pipeCheckpoint, concurrency int) (string, *pipeline) {
+	t.Helper()
+	var out bytes.Buffer
+	p, err := newCSVPipeline(strings.NewReader(input), &out, map[string]string{"name": "name"}, cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.call = upperCall
+	if err := p.run(concurrency); err != nil {
+		t.Fatal(err)
+	}
+	return out.String(), p
+}
+
+func TestPipeCSV(t *testing.T) {
+	out, p := runCSV(t, "id,name\n1,ann\n2,bad\n3,bo\n", pipeCheckpoint{}, 2)
+	want := "id,name,Upper,result_name,error\n1,ann,ANN,collides,\n2,bad,,,invalid name\n3,bo,BO,collides,\n"
+	if out != want {
+		t.Errorf("output =\n%s\nwant\n%s", out, want)
+	}
+	if p.failed != 1 || p.checkpoint.Rows != 3 {
+		t.Errorf("failed %d, rows %d", p.failed, p.checkpoint.Rows)
+	}
+}
+
+// When more rows fail than fit in the read-ahead window before any
+// succeeds, the failed rows are written without waiting for result columns,
+// and later results go into a single column as JSON. Reading never runs
+// more than the window ahead of the rows written.
+func TestPipeCSVColumnsAfterLongFailureRun(t *testing.T) {
+	const concurrency = 2
+	window := concurrency * pipeWindow
+	var in strings.Builder
+	in.WriteString("name\n")
+	failures := window + 5
+	for i := 0; i < failures; i++ {
+		fmt.Fprintf(&in, "bad%d\n", i)
+	}
+	in.WriteString("ann\n")
+
+	var out bytes.Buffer
+	p, err := newCSVPipeline(strings.NewReader(in.String()), &out, map[string]string{"name": "name"}, pipeCheckpoint{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, reads, written := p.read, 0, 0
+	p.read = func() (*pipeRow, error) {
+		row, err := read()
+		if err == nil {
+			if reads-written >= window {
+				t.Errorf("read row %d with only %d written", reads, written)
+			}
+			reads++
+		}
+		return row, err
+	}
+	write := p.write
+	p.write = func(row *pipeRow) error {
+		written++
+		return write(row)
+	}
+	p.call = upperCall
+	if err := p.run(concurrency); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if lines[0] != "name,result,error" {
+		t.Errorf("header = %q", lines[0])
+	}
+	if lines[1] != "bad0,,invalid name" {
+		t.Errorf("first row = %q", lines[1])
+	}
+	if last := lines[len(lines)-1]; last != `ann,"{""Upper"":""ANN"",""name"":""collides""}",` {
+		t.Errorf("last row = %q", last)
+	}
+	if len(lines) != failures+2 || p.failed != failures || !p.checkpoint.RawResult {
+		t.Errorf("%d lines, %d failed, checkpoint %+v", len(lines), p.failed, p.checkpoint)
+	}
+
+	// A resumed run keeps writing results as JSON
+	resumed, _ := runCSV(t, "name\nbad0\nbo\n", pipeCheckpoint{Rows: 1, RawResult: true}, 1)
+	if want := "bo,\"{\"\"Upper\"\":\"\"BO\"\",\"\"name\"\":\"\"collides\"\"}\",\n"; resumed != want {
+		t.Errorf("resumed output = %q, want %q", resumed, want)
+	}
+}
+
+func TestPipeCSVAllFailed(t *testing.T) {
+	out, _ := runCSV(t, "name\nbad1\nbad2\n", pipeCheckpoint{}, 1)
+	if want := "name,error\nbad1,invalid name\nbad2,invalid name\n"; out != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+	if out, _ := runCSV(t, "name\n", pipeCheckpoint{}, 1); out != "name,error\n" {
+		t.Errorf("empty input output = %q", out)
+	}
+}
+
+func TestPipeOrder(t *testing.T) {
+	var in, want strings.Builder
+	in.WriteString("name\n")
+	want.WriteString("name,Upper,result_name,error\n")
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&in, "n%d\n", i)
+		fmt.Fprintf(&want, "n%d,N%d,collides,\n", i, i)
+	}
+
+	var out bytes.Buffer
+	p, _ := newCSVPipeline(strings.NewReader(in.String()), &out, map[string]string{"name": "name"}, pipeCheckpoint{})
+	p.call = func(row *pipeRow) {
+		// Later rows finish first
+		time.Sleep(time.Duration(200-row.index) * 10 * time.Microsecond)
+		upperCall(row)
+	}
+	if err := p.run(8); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != want.String() {
+		t.Error("rows were not written in input order")
+	}
+}
+
+func TestPipeCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	input := "name\nann\nbo\ncy\n"
+
+	var out bytes.Buffer
+	p, _ := newCSVPipeline(strings.NewReader("name\nann\n"), &out, map[string]string{"name": "name"}, pipeCheckpoint{})
+	p.call = upperCall
+	p.checkpointPath = path
+	if err := p.run(1); err != nil {
+		t.Fatal(err)
+	}
+
+	cp, err := readCheckpoint(path)
+	if err != nil || cp.Rows != 1 {
+		t.Fatalf("checkpoint = %+v, %v", cp, err)
+	}
+
+	// The resumed run skips the written row and does not repeat the header
+	var mu sync.Mutex
+	var called []string
+	p, _ = newCSVPipeline(strings.NewReader(input), &out, map[string]string{"name": "name"}, cp)
+	p.call = func(row *pipeRow) {
+		mu.Lock()
+		called = append(called, row.value("name").(string))
+		mu.Unlock()
+		upperCall(row)
+	}
+	p.checkpointPath = path
+	if err := p.run(1); err != nil {
+		t.Fatal(err)
+	}
+	want := "name,Upper,result_name,error\nann,ANN,collides,\nbo,BO,collides,\ncy,CY,collides,\n"
+	sort.Strings(called)
+	if out.String() != want || strings.Join(called, ",") != "bo,cy" {
+		t.Errorf("output =\n%s\ncalled %v", out.String(), called)
+	}
+	if cp, _ := readCheckpoint(path); cp.Rows != 3 {
+		t.Errorf("checkpoint rows = %d, want 3", cp.Rows)
+	}
+
+	if cp, err := readCheckpoint(filepath.Join(t.TempDir(), "missing.json")); err != nil || cp.Rows != 0 {
+		t.Errorf("missing checkpoint = %+v, %v", cp, err)
+	}
+	os.WriteFile(path, []byte("{"), 0o600)
+	if _, err := readCheckpoint(path); err == nil {
+		t.Error("corrupt checkpoint accepted")
+	}
+}
+
+func TestPipeNDJSON(t *testing.T) {
+	var out bytes.Buffer
+	p, err := newNDJSONPipeline(strings.NewReader(`{"name":"ann","n":1}`+"\n"+`{"name":"bad"}`), &out, map[string]string{"name": "name"}, pipeCheckpoint{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.call = upperCall
+	if err := p.run(2); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"Upper":"ANN","n":1,"name":"ann","result_name":"collides"}` + "\n" + `{"error":"invalid name","name":"bad"}` + "\n"
+	if out.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestBindColumns(t *testing.T) {
+	s := newTestServer()
+	single := s.GetTool("interzoid_gender").Tool
+	pair := s.GetTool("interzoid_org_match_score").Tool
+
+	if b, err := bindColumns(single, []string{"first_name"}); err != nil || b["name"] != "first_name" {
+		t.Errorf("bindColumns(single) = %v, %v", b, err)
+	}
+	if b, err := bindColumns(pair, []string{"org1=a", "org2=b"}); err != nil || b["org1"] != "a" || b["org2"] != "b" {
+		t.Errorf("bindColumns(pair) = %v, %v", b, err)
+	}
+	if _, err := bindColumns(pair, []string{"a"}); err == nil {
+		t.Error("a bare column was bound for a tool with two parameters")
+	}
+	if _, err := bindColumns(single, []string{"nope=a"}); err == nil {
+		t.Error("an unknown parameter was bound")
+	}
+}
+
+// Rows that hit the inbound rate limit wait and are retried instead of
+// failing.
+func TestCallPipeRowWaitsOutRateLimit(t *testing.T) {
+	previous := inboundLimiter.Load()
+	inboundLimiter.Store(newRateLimiter(60, 1, false))
+	t.Cleanup(func() { inboundLimiter.Store(previous) })
+	calls := stubUpstream(t, answer(`{"Gender":"F","Code":"Success"}`))
+	s := newTestServer()
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		row := &pipeRow{object: map[string]interface{}{"first": "Maria" + strconv.Itoa(i)}}
+		callPipeRow(context.Background(), s, "interzoid_gender", map[string]string{"name": "first"}, nil, row)
+		if row.err != "" || row.result["Gender"] != "F" {
+			t.Fatalf("row %d = %v, %q", i, row.result, row.err)
+		}
+	}
+	if calls() != 2 || time.Since(start) < 500*time.Millisecond {
+		t.Errorf("%d upstream calls in %v; want the second to wait for the limit", calls(), time.Since(start))
+	}
+}
+
+// The rows of a run share one session, whose budget caps the run.
+func TestCallPipeRowSessionBudget(t *testing.T) {
+	withSessionBudget(t, standardTier.atomicUSDC)
+	calls := stubUpstream(t, answer(`{"Gender":"F","Code":"Success"}`))
+	s := newTestServer()
+	ctx := withRunSession(context.Background(), s)
+
+	var errs []string
+	for i := 0; i < 2; i++ {
+		row := &pipeRow{object: map[string]interface{}{"first": "Maria" + strconv.Itoa(i)}}
+		callPipeRow(ctx, s, "interzoid_gender", map[string]string{"name": "first"}, nil, row)
+		errs = append(errs, row.err)
+	}
+	if errs[0] != "" || !strings.HasPrefix(errs[1], string(codeBudgetExceeded)+":") {
+		t.Errorf("row errors = %q, want the second row over budget", errs)
+	}
+	if calls() != 1 {
+		t.Errorf("upstream calls = %d, want 1", calls())
+	}
+}
@@ -0,0 +1,52 @@
+package mcpserver
+
+// x402 payment settlement: USDC on Base mainnet.
+const (
+	x402Network = "eip155:8453"
+	usdcAsset   = "0x833589fCD6eDb6E08f4c7C32D4f71b54bdA02913"
+)
+
+// priceTier describes what one call to an Interzoid API costs, both via x402
+// (USDC on Base, 6 decimals) and in account credits when using an API key.
+type priceTier struct {
+	name       string
+	atomicUSDC int64
+	credits    int64
+}
+
+var (
+	freeTier     = priceTier{name: "free"}
+	standardTier = priceTier{name: "standard", atomicUSDC: 12500, credits: 1}  // $0.0125
+	premiumTier  = priceTier{name: "premium", atomicUSDC: 312500, credits: 25} // $0.3125
+)
+
+// usd returns the per-call price in dollars.
+func (t priceTier) usd() float64 {
+	return float64(t.atomicUSDC) / 1e6
+}
+
+// premiumEndpoints lists the AI-powered enrichment APIs billed at the premium
+// tier. Every other endpoint is billed at the standard tier.
+var premiumEndpoints = map[string]bool{
+	"/getbusinessinfo":        true,
+	"/getparentcompanyinfo":   true,
+	"/getexecutiveprofile":    true,
+	"/getrecentnews":          true,
+	"/emailtrustscore":        true,
+	"/getipprofile":           true,
+	"/getphoneprofile":        true,
+	"/getcompanyverification": true,
+	"/getstockinfo":           true,
+}
+
+// tierForEndpoint returns the price tier of an Interzoid API endpoint.
+func tierForEndpoint(endpoint string) priceTier {
+	switch {
+	case endpoint == creditsEndpoint:
+		return freeTier
+	case premiumEndpoints[endpoint]:
+		return premiumTier
+	default:
+		return standardTier
+	}
+}
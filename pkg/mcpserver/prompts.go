@@ -0,0 +1,306 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============================================================================
+// MCP PROMPTS — Data-Quality Workflows
+// ============================================================================
+//
+// Parameterized prompt templates for common workflows. Each one names the
+// interzoid_* tools to use, in order, and states what the workflow costs so
+// the agent can warn the user before running paid lookups.
+// ============================================================================
+
+// workflowPrompt is a workflow prompt and the tools its instructions name.
+type workflowPrompt struct {
+	prompt  mcp.Prompt
+	tools   []string
+	handler func(prices toolPrices, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error)
+}
+
+// toolPrices maps each tool a server offers to its per-call price in USD.
+type toolPrices map[string]float64
+
+// offers reports whether every one of tools is offered.
+func (p toolPrices) offers(tools []string) bool {
+	for _, t := range tools {
+		if _, ok := p[t]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// registerPrompts registers the workflow prompts whose tools s offers, so a
+// prompt never names a tool the tools section of the configuration removed.
+// Call it after the tools are registered and filtered.
+func registerPrompts(s *server.MCPServer) {
+	s.AddPrompts(offeredPrompts(s)...)
+}
+
+// offeredPrompts returns the workflow prompts whose tools s offers. Prices
+// come from each tool's price tier.
+func offeredPrompts(s *server.MCPServer) []server.ServerPrompt {
+	prices := make(toolPrices)
+	for name, tool := range s.ListTools() {
+		prices[name] = toolTier(tool.Tool).usd()
+	}
+
+	var offered []server.ServerPrompt
+	for _, p := range workflowPrompts {
+		if !prices.offers(p.tools) {
+			continue
+		}
+		handler := p.handler
+		offered = append(offered, server.ServerPrompt{
+			Prompt: p.prompt,
+			Handler: func(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+				return handler(prices, request)
+			},
+		})
+	}
+	return offered
+}
+
+var workflowPrompts = []workflowPrompt{
+	{
+		prompt: mcp.NewPrompt("deduplicate_company_list",
+			mcp.WithPromptDescription("Find duplicate companies in a list using similarity keys, e.g. 'IBM' and 'International Business Machines'."),
+			mcp.WithArgument("companies", mcp.RequiredArgument(), mcp.ArgumentDescription("Company names, one per line or comma-separated")),
+			mcp.WithArgument("algorithm", mcp.ArgumentDescription("Matching algorithm for interzoid_company_match_advanced (optional)")),
+		),
+		tools:   []string{"interzoid_company_match_advanced", "interzoid_org_match_score"},
+		handler: dedupeCompaniesPrompt,
+	},
+	{
+		prompt: mcp.NewPrompt("standardize_address_column",
+			mcp.WithPromptDescription("Parse, standardize and generate match keys for a column of street addresses."),
+			mcp.WithArgument("addresses", mcp.RequiredArgument(), mcp.ArgumentDescription("Addresses, one per line")),
+			mcp.WithArgument("international", mcp.ArgumentDescription("'yes' if the addresses are outside the US (optional)")),
+		),
+		tools: []string{
+			"interzoid_address_parse", "interzoid_state_abbreviation", "interzoid_city_standard",
+			"interzoid_address_match_advanced", "interzoid_global_address_match", "interzoid_country_standard",
+		},
+		handler: standardizeAddressesPrompt,
+	},
+	{
+		prompt: mcp.NewPrompt("kyb_vendor_check",
+			mcp.WithPromptDescription("Know-your-business check on a vendor: verify it exists, profile it, find its parent company and recent news."),
+			mcp.WithArgument("vendor", mcp.RequiredArgument(), mcp.ArgumentDescription("Vendor company name or website")),
+			mcp.WithArgument("contact_email", mcp.ArgumentDescription("Vendor contact email to score (optional)")),
+		),
+		tools: []string{
+			"interzoid_company_verification", "interzoid_business_info", "interzoid_parent_company_info",
+			"interzoid_recent_news", "interzoid_email_trust_score",
+		},
+		handler: kybVendorPrompt,
+	},
+	{
+		prompt: mcp.NewPrompt("triage_contact_risk",
+			mcp.WithPromptDescription("Assess the risk of a contact from their email address, phone number and/or IP address."),
+			mcp.WithArgument("email", mcp.ArgumentDescription("Email address (optional)")),
+			mcp.WithArgument("phone", mcp.ArgumentDescription("Phone number (optional)")),
+			mcp.WithArgument("ip", mcp.ArgumentDescription("IP address (optional)")),
+		),
+		tools:   []string{"interzoid_email_trust_score", "interzoid_phone_profile", "interzoid_ip_profile"},
+		handler: contactRiskPrompt,
+	},
+}
+
+// costNote describes the cost of calling a tool n times.
+func (p toolPrices) costNote(name string, n int) string {
+	price := p[name]
+	if n == 1 {
+		return fmt.Sprintf("`%s` costs $%.4f per call", name, price)
+	}
+	return fmt.Sprintf("`%s` costs $%.4f per call, about $%.4f for %d values", name, price, price*float64(n), n)
+}
+
+// splitLines splits prompt input into trimmed, non-empty lines.
+func splitLines(s string) []string {
+	return splitOn(s, "\n")
+}
+
+// splitList splits prompt input on newlines, or on commas when it is a
+// single line.
+func splitList(s string) []string {
+	if strings.Contains(s, "\n") {
+		return splitOn(s, "\n")
+	}
+	return splitOn(s, ",")
+}
+
+func splitOn(s, sep string) []string {
+	var items []string
+	for _, item := range strings.Split(s, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// promptResult wraps workflow instructions as a single user message.
+func promptResult(description, text string) *mcp.GetPromptResult {
+	return mcp.NewGetPromptResult(description, []mcp.PromptMessage{
+		mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text)),
+	})
+}
+
+func dedupeCompaniesPrompt(prices toolPrices, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	companies := splitList(request.Params.Arguments["companies"])
+	if len(companies) == 0 {
+		return nil, fmt.Errorf("companies must list at least one company name")
+	}
+
+	algorithm := ""
+	if a := request.Params.Arguments["algorithm"]; a != "" {
+		algorithm = fmt.Sprintf(" with algorithm=%q", a)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Deduplicate the following list of %d companies.\n\n", len(companies))
+	for _, c := range companies {
+		fmt.Fprintf(&b, "- %s\n", c)
+	}
+	fmt.Fprintf(&b, `
+Steps:
+1. Call interzoid_company_match_advanced%s to get a similarity key for each name. Pass the names as an array of up to %d per call.
+2. Group names that share the same similarity key; each group is one real-world company.
+3. For borderline pairs you are unsure about, confirm with interzoid_org_match_score (a score of 80 or more usually means the same entity).
+4. Report each group with a suggested canonical name, and list names that had no duplicates.
+
+Cost: %s. %s only for pairs you check.
+Tell the user the estimated cost before starting.
+`, algorithm, maxBatchSize,
+		prices.costNote("interzoid_company_match_advanced", len(companies)),
+		prices.costNote("interzoid_org_match_score", 1))
+
+	return promptResult("Deduplicate a company list", b.String()), nil
+}
+
+func standardizeAddressesPrompt(prices toolPrices, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	// Addresses contain commas, so only split on newlines
+	cleaned := splitLines(request.Params.Arguments["addresses"])
+	if len(cleaned) == 0 {
+		return nil, fmt.Errorf("addresses must list at least one address")
+	}
+
+	matchTool := "interzoid_address_match_advanced"
+	if strings.EqualFold(request.Params.Arguments["international"], "yes") {
+		matchTool = "interzoid_global_address_match"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Standardize the following column of %d addresses.\n\n", len(cleaned))
+	for _, a := range cleaned {
+		fmt.Fprintf(&b, "- %s\n", a)
+	}
+	fmt.Fprintf(&b, "\nSteps:\n")
+	if matchTool == "interzoid_address_match_advanced" {
+		fmt.Fprintf(&b, `1. Call interzoid_address_parse on each address to split it into street, unit, city, state and ZIP.
+2. Normalize the parts: interzoid_state_abbreviation for states and interzoid_city_standard for city names. Call each once per distinct value, not once per row.
+3. Call %s for each address to get a similarity key, so that variants of the same address can be matched.
+4. Return a table with the original address, the standardized parts and the similarity key, and flag addresses that share a key.
+
+Cost: %s. %s. State and city standardization costs the same per distinct value.
+`, matchTool, prices.costNote("interzoid_address_parse", len(cleaned)), prices.costNote(matchTool, len(cleaned)))
+	} else {
+		fmt.Fprintf(&b, `1. Call %s for each address to get a similarity key; it handles international formats directly.
+2. Use interzoid_country_standard once per distinct country to normalize country names.
+3. Return a table with the original address, the standardized country and the similarity key, and flag addresses that share a key.
+
+Cost: %s.
+`, matchTool, prices.costNote(matchTool, len(cleaned)))
+	}
+	fmt.Fprintf(&b, "Pass values as arrays of up to %d per call. Tell the user the estimated cost before starting.\n", maxBatchSize)
+
+	return promptResult("Standardize an address column", b.String()), nil
+}
+
+func kybVendorPrompt(prices toolPrices, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	vendor := strings.TrimSpace(request.Params.Arguments["vendor"])
+	if vendor == "" {
+		return nil, fmt.Errorf("vendor is required")
+	}
+	email := strings.TrimSpace(request.Params.Arguments["contact_email"])
+
+	tools := []string{
+		"interzoid_company_verification",
+		"interzoid_business_info",
+		"interzoid_parent_company_info",
+		"interzoid_recent_news",
+	}
+	if email != "" {
+		tools = append(tools, "interzoid_email_trust_score")
+	}
+	var total float64
+	for _, t := range tools {
+		total += prices[t]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Run a know-your-business (KYB) check on the vendor %q.\n\nSteps:\n", vendor)
+	fmt.Fprintf(&b, "1. Call interzoid_company_verification to confirm the company exists. If the verification score is low, stop and report why before spending more.\n")
+	fmt.Fprintf(&b, "2. Call interzoid_business_info for industry, size, revenue and leadership.\n")
+	fmt.Fprintf(&b, "3. Call interzoid_parent_company_info to identify the ultimate owner.\n")
+	fmt.Fprintf(&b, "4. Call interzoid_recent_news and highlight litigation, sanctions, layoffs or financial distress.\n")
+	if email != "" {
+		fmt.Fprintf(&b, "5. Call interzoid_email_trust_score with lookup=%q and check that the email domain matches the vendor.\n", email)
+	}
+	fmt.Fprintf(&b, `
+Finish with a risk rating (low / medium / high) and the evidence for it.
+
+Cost: these are premium APIs at $%.4f per call, about $%.4f for the full check.
+Tell the user the cost and ask for confirmation before starting.
+`, premiumTier.usd(), total)
+
+	return promptResult("KYB check on a vendor", b.String()), nil
+}
+
+func contactRiskPrompt(prices toolPrices, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	checks := []struct {
+		arg, tool, label string
+	}{
+		{"email", "interzoid_email_trust_score", "email address"},
+		{"phone", "interzoid_phone_profile", "phone number"},
+		{"ip", "interzoid_ip_profile", "IP address"},
+	}
+
+	var b strings.Builder
+	var total float64
+	step := 0
+	for _, c := range checks {
+		v := strings.TrimSpace(request.Params.Arguments[c.arg])
+		if v == "" {
+			continue
+		}
+		step++
+		total += prices[c.tool]
+		fmt.Fprintf(&b, "%d. Call %s with lookup=%q to assess the %s.\n", step, c.tool, v, c.label)
+	}
+	if step == 0 {
+		return nil, fmt.Errorf("provide at least one of email, phone or ip")
+	}
+
+	text := fmt.Sprintf(`Triage the risk of a contact.
+
+Steps:
+%s%d. Cross-check the results: a disposable email, a VoIP phone line, or an IP geolocation that does not match the contact's other details are each warning signs.
+
+Finish with a risk level (low / medium / high), the signals behind it, and a recommended action (accept, review manually, or reject).
+
+Cost: these are premium APIs, about $%.4f in total.
+Tell the user the cost before starting.
+`, b.String(), step+1, total)
+
+	return promptResult("Triage contact risk", text), nil
+}
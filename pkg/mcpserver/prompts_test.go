@@ -0,0 +1,93 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// getPrompt renders a prompt from a server with every tool and prompt
+// registered, returning its text or the JSON-RPC error message.
+func getPrompt(t *testing.T, name string, args map[string]string) (string, string) {
+	t.Helper()
+	s := server.NewMCPServer(serverName, serverVersion, server.WithPromptCapabilities(false))
+	registerAllTools(s)
+	registerPrompts(s)
+
+	message, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "prompts/get",
+		"params":  map[string]interface{}{"name": name, "arguments": args},
+	})
+	switch response := s.HandleMessage(context.Background(), message).(type) {
+	case mcp.JSONRPCResponse:
+		result := response.Result.(mcp.GetPromptResult)
+		return result.Messages[0].Content.(mcp.TextContent).Text, ""
+	case mcp.JSONRPCError:
+		return "", response.Error.Message
+	default:
+		t.Fatalf("unexpected response %#v", response)
+		return "", ""
+	}
+}
+
+func TestPrompts(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "deduplicate_company_list",
+			args: map[string]string{"companies": "IBM, International Business Machines, Apple", "algorithm": "ai-plus"},
+			want: []string{"list of 3 companies", `interzoid_company_match_advanced with algorithm="ai-plus"`, "about $0.0375 for 3 values"},
+		},
+		{
+			name: "standardize_address_column",
+			args: map[string]string{"addresses": "1 Main St, Springfield\n2 Oak Ave, Portland"},
+			want: []string{"column of 2 addresses", "interzoid_address_parse", "interzoid_address_match_advanced"},
+		},
+		{
+			name: "standardize_address_column",
+			args: map[string]string{"addresses": "10 Downing St, London", "international": "yes"},
+			want: []string{"interzoid_global_address_match", "interzoid_country_standard"},
+		},
+		{
+			name: "kyb_vendor_check",
+			args: map[string]string{"vendor": "Acme Corp", "contact_email": "ap@acme.com"},
+			want: []string{`vendor "Acme Corp"`, `lookup="ap@acme.com"`, "about $1.5625 for the full check"},
+		},
+		{
+			name: "triage_contact_risk",
+			args: map[string]string{"ip": "8.8.8.8"},
+			want: []string{`interzoid_ip_profile with lookup="8.8.8.8"`, "about $0.3125 in total"},
+		},
+		{name: "deduplicate_company_list", args: map[string]string{"companies": " , "}, wantErr: "at least one company"},
+		{name: "triage_contact_risk", args: map[string]string{}, wantErr: "at least one of email, phone or ip"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, errMsg := getPrompt(t, tt.name, tt.args)
+			if tt.wantErr != "" {
+				if !strings.Contains(errMsg, tt.wantErr) {
+					t.Errorf("error = %q, want %q", errMsg, tt.wantErr)
+				}
+				return
+			}
+			if errMsg != "" {
+				t.Fatal(errMsg)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("prompt does not contain %q:\n%s", want, text)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package mcpserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// inboundLimiter is the rate limiter applied to every tool call. It holds nil
+// (unlimited) unless enabled with the -rate-limit flag, and is replaced when a
+// configuration reload changes the rate limit settings.
+var inboundLimiter atomic.Pointer[rateLimiter]
+
+// rateLimiter enforces token-bucket limits on inbound tool calls so that a
+// single runaway agent cannot exhaust a shared Interzoid key.
+//
+// Buckets are keyed by caller (see callerID) and, when perTool is set, by
+// caller and tool name. Idle buckets are swept periodically so the map does
+// not grow without bound on a busy hosted instance.
+type rateLimiter struct {
+	rate    float64 // tokens added per second
+	burst   float64 // bucket capacity
+	perTool bool
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a limiter allowing perMinute calls per minute with
+// the given burst. A burst below 1 defaults to 1.
+func newRateLimiter(perMinute float64, burst int, perTool bool) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:      perMinute / 60,
+		burst:     float64(burst),
+		perTool:   perTool,
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// allow takes a token from the bucket for caller (and tool, when limiting per
+// tool). If the bucket is empty it returns false along with how long the
+// caller should wait before the next token becomes available.
+func (l *rateLimiter) allow(caller, tool string) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	key := caller
+	if l.perTool {
+		key = caller + "|" + tool
+	}
+
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep drops buckets that have been idle long enough to refill completely,
+// since they are indistinguishable from a fresh bucket. Called with l.mu held.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) > refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// checkRateLimit takes a token for the call from the caller's bucket, and
+// returns a *rateLimitError when there is none. Every tool that calls
+// upstream checks it before doing so.
+func checkRateLimit(ctx context.Context, request mcp.CallToolRequest) error {
+	if ok, wait := inboundLimiter.Load().allow(callerID(ctx, request), request.Params.Name); !ok {
+		return &rateLimitError{tool: request.Params.Name, retryAfter: wait}
+	}
+	return nil
+}
+
+// rateLimitError is returned by checkRateLimit when the caller is over its
+// limit. Tool handlers report it like any other failure, as a rate_limited
+// tool error with retryAfterSeconds.
+type rateLimitError struct {
+	tool       string
+	retryAfter time.Duration
+}
+
+func (e *rateLimitError) Error() string {
+	secs := int(math.Ceil(e.retryAfter.Seconds()))
+	return fmt.Sprintf("rate limit exceeded for tool %s; retry after %d seconds", e.tool, secs)
+}
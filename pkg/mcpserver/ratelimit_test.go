@@ -0,0 +1,94 @@
+package mcpserver
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBurst(t *testing.T) {
+	l := newRateLimiter(60, 2, false)
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.allow("ip:1.2.3.4", "interzoid_gender"); !ok {
+			t.Fatalf("call %d within the burst was refused", i+1)
+		}
+	}
+	ok, wait := l.allow("ip:1.2.3.4", "interzoid_gender")
+	if ok {
+		t.Fatal("call over the burst was allowed")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("wait = %v, want up to the 1s it takes to earn a token", wait)
+	}
+
+	// Another caller has its own bucket
+	if ok, _ := l.allow("ip:5.6.7.8", "interzoid_gender"); !ok {
+		t.Error("a different caller was refused")
+	}
+}
+
+func TestRateLimiterPerTool(t *testing.T) {
+	tests := []struct {
+		perTool bool
+		want    bool // whether a second tool is allowed once the first is spent
+	}{
+		{false, false},
+		{true, true},
+	}
+	for _, tt := range tests {
+		l := newRateLimiter(60, 1, tt.perTool)
+		l.allow("local", "interzoid_gender")
+		if ok, _ := l.allow("local", "interzoid_name_origin"); ok != tt.want {
+			t.Errorf("perTool=%v: second tool allowed = %v, want %v", tt.perTool, ok, tt.want)
+		}
+	}
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	var l *rateLimiter
+	for i := 0; i < 100; i++ {
+		if ok, _ := l.allow("local", "interzoid_gender"); !ok {
+			t.Fatal("a nil limiter refused a call")
+		}
+	}
+}
+
+func TestRateLimiterSweep(t *testing.T) {
+	l := newRateLimiter(60, 1, false)
+	l.allow("local", "interzoid_gender")
+	l.buckets["local"].last = time.Now().Add(-time.Hour)
+	l.lastSweep = time.Now().Add(-time.Hour)
+
+	l.allow("ip:1.2.3.4", "interzoid_gender")
+	if _, ok := l.buckets["local"]; ok {
+		t.Error("an idle, full bucket was not swept")
+	}
+}
+
+// A caller over its limit gets a rate_limited tool error with a retry hint,
+// and no upstream call is made.
+func TestRateLimitedToolCall(t *testing.T) {
+	calls := stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"Gender":"F","Code":"Success","Credits":"100"}`)
+	})
+	inboundLimiter.Store(newRateLimiter(60, 1, false))
+	t.Cleanup(func() { inboundLimiter.Store(nil) })
+
+	args := map[string]interface{}{"name": "Maria"}
+	if _, err := callToolHandler(context.Background(), t, "interzoid_gender", args, nil); err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	result, err := callToolHandler(context.Background(), t, "interzoid_gender", args, nil)
+	if err != nil || !result.IsError {
+		t.Fatalf("second call = %v, %v, want a tool error", resultText(result), err)
+	}
+	body := parseToolError(resultText(result))
+	if body.Code != codeRateLimited || !body.Retryable || body.RetryAfterSeconds != 1 {
+		t.Errorf("error = %+v, want retryable rate_limited after 1 second", body)
+	}
+	if calls() != 1 {
+		t.Errorf("upstream calls = %d, want 1", calls())
+	}
+}
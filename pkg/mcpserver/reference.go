@@ -0,0 +1,240 @@
+package mcpserver
+
+// ============================================================================
+// BUNDLED REFERENCE TABLES
+// ============================================================================
+//
+// Static reference data used to validate arguments before they are sent to
+// the Interzoid API and to answer argument completion requests.
+// ============================================================================
+
+// iso4217Currencies maps active ISO 4217 currency codes to their names.
+var iso4217Currencies = map[string]string{
+	"AED": "UAE Dirham",
+	"AFN": "Afghani",
+	"ALL": "Lek",
+	"AMD": "Armenian Dram",
+	"ANG": "Netherlands Antillean Guilder",
+	"AOA": "Kwanza",
+	"ARS": "Argentine Peso",
+	"AUD": "Australian Dollar",
+	"AWG": "Aruban Florin",
+	"AZN": "Azerbaijan Manat",
+	"BAM": "Convertible Mark",
+	"BBD": "Barbados Dollar",
+	"BDT": "Taka",
+	"BGN": "Bulgarian Lev",
+	"BHD": "Bahraini Dinar",
+	"BIF": "Burundi Franc",
+	"BMD": "Bermudian Dollar",
+	"BND": "Brunei Dollar",
+	"BOB": "Boliviano",
+	"BRL": "Brazilian Real",
+	"BSD": "Bahamian Dollar",
+	"BTN": "Ngultrum",
+	"BWP": "Pula",
+	"BYN": "Belarusian Ruble",
+	"BZD": "Belize Dollar",
+	"CAD": "Canadian Dollar",
+	"CDF": "Congolese Franc",
+	"CHF": "Swiss Franc",
+	"CLP": "Chilean Peso",
+	"CNY": "Yuan Renminbi",
+	"COP": "Colombian Peso",
+	"CRC": "Costa Rican Colon",
+	"CUP": "Cuban Peso",
+	"CVE": "Cabo Verde Escudo",
+	"CZK": "Czech Koruna",
+	"DJF": "Djibouti Franc",
+	"DKK": "Danish Krone",
+	"DOP": "Dominican Peso",
+	"DZD": "Algerian Dinar",
+	"EGP": "Egyptian Pound",
+	"ERN": "Nakfa",
+	"ETB": "Ethiopian Birr",
+	"EUR": "Euro",
+	"FJD": "Fiji Dollar",
+	"FKP": "Falkland Islands Pound",
+	"GBP": "Pound Sterling",
+	"GEL": "Lari",
+	"GHS": "Ghana Cedi",
+	"GIP": "Gibraltar Pound",
+	"GMD": "Dalasi",
+	"GNF": "Guinean Franc",
+	"GTQ": "Quetzal",
+	"GYD": "Guyana Dollar",
+	"HKD": "Hong Kong Dollar",
+	"HNL": "Lempira",
+	"HTG": "Gourde",
+	"HUF": "Forint",
+	"IDR": "Rupiah",
+	"ILS": "New Israeli Sheqel",
+	"INR": "Indian Rupee",
+	"IQD": "Iraqi Dinar",
+	"IRR": "Iranian Rial",
+	"ISK": "Iceland Krona",
+	"JMD": "Jamaican Dollar",
+	"JOD": "Jordanian Dinar",
+	"JPY": "Yen",
+	"KES": "Kenyan Shilling",
+	"KGS": "Som",
+	"KHR": "Riel",
+	"KMF": "Comorian Franc",
+	"KPW": "North Korean Won",
+	"KRW": "Won",
+	"KWD": "Kuwaiti Dinar",
+	"KYD": "Cayman Islands Dollar",
+	"KZT": "Tenge",
+	"LAK": "Lao Kip",
+	"LBP": "Lebanese Pound",
+	"LKR": "Sri Lanka Rupee",
+	"LRD": "Liberian Dollar",
+	"LSL": "Loti",
+	"LYD": "Libyan Dinar",
+	"MAD": "Moroccan Dirham",
+	"MDL": "Moldovan Leu",
+	"MGA": "Malagasy Ariary",
+	"MKD": "Denar",
+	"MMK": "Kyat",
+	"MNT": "Tugrik",
+	"MOP": "Pataca",
+	"MRU": "Ouguiya",
+	"MUR": "Mauritius Rupee",
+	"MVR": "Rufiyaa",
+	"MWK": "Malawi Kwacha",
+	"MXN": "Mexican Peso",
+	"MYR": "Malaysian Ringgit",
+	"MZN": "Mozambique Metical",
+	"NAD": "Namibia Dollar",
+	"NGN": "Naira",
+	"NIO": "Cordoba Oro",
+	"NOK": "Norwegian Krone",
+	"NPR": "Nepalese Rupee",
+	"NZD": "New Zealand Dollar",
+	"OMR": "Rial Omani",
+	"PAB": "Balboa",
+	"PEN": "Sol",
+	"PGK": "Kina",
+	"PHP": "Philippine Peso",
+	"PKR": "Pakistan Rupee",
+	"PLN": "Zloty",
+	"PYG": "Guarani",
+	"QAR": "Qatari Rial",
+	"RON": "Romanian Leu",
+	"RSD": "Serbian Dinar",
+	"RUB": "Russian Ruble",
+	"RWF": "Rwanda Franc",
+	"SAR": "Saudi Riyal",
+	"SBD": "Solomon Islands Dollar",
+	"SCR": "Seychelles Rupee",
+	"SDG": "Sudanese Pound",
+	"SEK": "Swedish Krona",
+	"SGD": "Singapore Dollar",
+	"SHP": "Saint Helena Pound",
+	"SLE": "Leone",
+	"SOS": "Somali Shilling",
+	"SRD": "Surinam Dollar",
+	"SSP": "South Sudanese Pound",
+	"STN": "Dobra",
+	"SVC": "El Salvador Colon",
+	"SYP": "Syrian Pound",
+	"SZL": "Lilangeni",
+	"THB": "Baht",
+	"TJS": "Somoni",
+	"TMT": "Turkmenistan New Manat",
+	"TND": "Tunisian Dinar",
+	"TOP": "Pa'anga",
+	"TRY": "Turkish Lira",
+	"TTD": "Trinidad and Tobago Dollar",
+	"TWD": "New Taiwan Dollar",
+	"TZS": "Tanzanian Shilling",
+	"UAH": "Hryvnia",
+	"UGX": "Uganda Shilling",
+	"USD": "US Dollar",
+	"UYU": "Peso Uruguayo",
+	"UZS": "Uzbekistan Sum",
+	"VES": "Bolivar Soberano",
+	"VND": "Dong",
+	"VUV": "Vatu",
+	"WST": "Tala",
+	"XAF": "CFA Franc BEAC",
+	"XCD": "East Caribbean Dollar",
+	"XOF": "CFA Franc BCEAO",
+	"XPF": "CFP Franc",
+	"YER": "Yemeni Rial",
+	"ZAR": "Rand",
+	"ZMW": "Zambian Kwacha",
+	"ZWL": "Zimbabwe Dollar",
+}
+
+// worldLanguages lists target language names accepted by
+// interzoid_translate_to_any.
+var worldLanguages = []string{
+	"Afrikaans", "Albanian", "Amharic", "Arabic", "Armenian", "Azerbaijani",
+	"Basque", "Belarusian", "Bengali", "Bosnian", "Bulgarian", "Burmese",
+	"Catalan", "Chinese (Simplified)", "Chinese (Traditional)", "Croatian", "Czech",
+	"Danish", "Dutch", "English", "Estonian", "Filipino", "Finnish", "French",
+	"Galician", "Georgian", "German", "Greek", "Gujarati", "Haitian Creole",
+	"Hausa", "Hebrew", "Hindi", "Hungarian", "Icelandic", "Igbo", "Indonesian",
+	"Irish", "Italian", "Japanese", "Javanese", "Kannada", "Kazakh", "Khmer",
+	"Korean", "Kurdish", "Kyrgyz", "Lao", "Latin", "Latvian", "Lithuanian",
+	"Luxembourgish", "Macedonian", "Malagasy", "Malay", "Malayalam", "Maltese",
+	"Maori", "Marathi", "Mongolian", "Nepali", "Norwegian", "Pashto", "Persian",
+	"Polish", "Portuguese", "Punjabi", "Romanian", "Russian", "Samoan",
+	"Serbian", "Sinhala", "Slovak", "Slovenian", "Somali", "Spanish", "Swahili",
+	"Swedish", "Tajik", "Tamil", "Telugu", "Thai", "Turkish", "Ukrainian",
+	"Urdu", "Uzbek", "Vietnamese", "Welsh", "Xhosa", "Yiddish", "Yoruba", "Zulu",
+}
+
+// countryNames lists canonical country names, as suggestions for
+// interzoid_country_standard and interzoid_country_info.
+var countryNames = []string{
+	"Afghanistan", "Albania", "Algeria", "Andorra", "Angola", "Antigua and Barbuda",
+	"Argentina", "Armenia", "Australia", "Austria", "Azerbaijan", "Bahamas",
+	"Bahrain", "Bangladesh", "Barbados", "Belarus", "Belgium", "Belize", "Benin",
+	"Bhutan", "Bolivia", "Bosnia and Herzegovina", "Botswana", "Brazil", "Brunei",
+	"Bulgaria", "Burkina Faso", "Burundi", "Cabo Verde", "Cambodia", "Cameroon",
+	"Canada", "Central African Republic", "Chad", "Chile", "China", "Colombia",
+	"Comoros", "Congo", "Costa Rica", "Cote d'Ivoire", "Croatia", "Cuba", "Cyprus",
+	"Czech Republic", "Democratic Republic of the Congo", "Denmark", "Djibouti",
+	"Dominica", "Dominican Republic", "Ecuador", "Egypt", "El Salvador",
+	"Equatorial Guinea", "Eritrea", "Estonia", "Eswatini", "Ethiopia", "Fiji",
+	"Finland", "France", "Gabon", "Gambia", "Georgia", "Germany", "Ghana", "Greece",
+	"Grenada", "Guatemala", "Guinea", "Guinea-Bissau", "Guyana", "Haiti",
+	"Honduras", "Hong Kong", "Hungary", "Iceland", "India", "Indonesia", "Iran",
+	"Iraq", "Ireland", "Israel", "Italy", "Jamaica", "Japan", "Jordan",
+	"Kazakhstan", "Kenya", "Kiribati", "Kosovo", "Kuwait", "Kyrgyzstan", "Laos",
+	"Latvia", "Lebanon", "Lesotho", "Liberia", "Libya", "Liechtenstein",
+	"Lithuania", "Luxembourg", "Macau", "Madagascar", "Malawi", "Malaysia",
+	"Maldives", "Mali", "Malta", "Marshall Islands", "Mauritania", "Mauritius",
+	"Mexico", "Micronesia", "Moldova", "Monaco", "Mongolia", "Montenegro",
+	"Morocco", "Mozambique", "Myanmar", "Namibia", "Nauru", "Nepal",
+	"Netherlands", "New Zealand", "Nicaragua", "Niger", "Nigeria", "North Korea",
+	"North Macedonia", "Norway", "Oman", "Pakistan", "Palau", "Palestine",
+	"Panama", "Papua New Guinea", "Paraguay", "Peru", "Philippines", "Poland",
+	"Portugal", "Puerto Rico", "Qatar", "Romania", "Russia", "Rwanda",
+	"Saint Kitts and Nevis", "Saint Lucia", "Saint Vincent and the Grenadines",
+	"Samoa", "San Marino", "Sao Tome and Principe", "Saudi Arabia", "Senegal",
+	"Serbia", "Seychelles", "Sierra Leone", "Singapore", "Slovakia", "Slovenia",
+	"Solomon Islands", "Somalia", "South Africa", "South Korea", "South Sudan",
+	"Spain", "Sri Lanka", "Sudan", "Suriname", "Sweden", "Switzerland", "Syria",
+	"Taiwan", "Tajikistan", "Tanzania", "Thailand", "Timor-Leste", "Togo",
+	"Tonga", "Trinidad and Tobago", "Tunisia", "Turkey", "Turkmenistan",
+	"Tuvalu", "Uganda", "Ukraine", "United Arab Emirates", "United Kingdom",
+	"United States", "Uruguay", "Uzbekistan", "Vanuatu", "Vatican City",
+	"Venezuela", "Vietnam", "Yemen", "Zambia", "Zimbabwe",
+}
+
+// algorithmVariants lists the algorithm values accepted by each tool that
+// takes an optional algorithm parameter. Keep in sync with the Interzoid API
+// documentation for each endpoint. When a call gives none, no algorithm is
+// sent and Interzoid picks its own default.
+var algorithmVariants = map[string][]string{
+	"interzoid_company_match_advanced": {"model-v4-narrow", "model-v4-wide", "ai-medium", "ai-plus", "ai-deep"},
+	"interzoid_address_match_advanced": {"model-v3-narrow", "model-v3-wide", "ai-medium", "ai-plus"},
+	"interzoid_product_match":          {"model-v1-narrow", "model-v1-wide", "ai-medium", "ai-plus"},
+	"interzoid_country_standard":       {"ai-medium", "ai-plus"},
+	"interzoid_country_info":           {"ai-medium", "ai-plus"},
+	"interzoid_state_abbreviation":     {"ai-medium", "ai-plus"},
+	"interzoid_city_standard":          {"ai-medium", "ai-plus"},
+}
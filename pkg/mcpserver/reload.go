@@ -0,0 +1,181 @@
+package mcpserver
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============================================================================
+// CONFIGURATION RELOAD
+// ============================================================================
+//
+// On SIGHUP, and when -watch-config is set and the configuration file
+// changes, the server re-reads the file, the environment and its original
+// command-line flags, and applies the result to the running server without
+// dropping sessions:
+//
+//   - tools.include / tools.exclude: tools are added to or removed from the
+//     live server, which notifies clients with notifications/tools/list_changed
+//     (and notifications/prompts/list_changed, as prompts follow their tools)
+//   - rate_limit, breaker and cache: replaced, losing their state, when changed
+//   - credits and budgets: applied to the next call
+//   - logging.file: reopened, which also suits log rotation
+//
+// Any other setting needs a restart; a reload that changes one logs it and
+// keeps the running value. An invalid configuration is logged and ignored.
+// ============================================================================
+
+// configPollInterval is how often a watched configuration file is checked
+// for changes.
+const configPollInterval = 2 * time.Second
+
+// reloader applies configuration reloads to a running server.
+type reloader struct {
+	s       *server.MCPServer
+	catalog *toolCatalog // the server's, see buildServer
+	cfg     *config      // settings in effect
+	args    []string     // command-line arguments, applied over every reload
+}
+
+func newReloader(s *server.MCPServer, catalog *toolCatalog, cfg *config, args []string) *reloader {
+	return &reloader{s: s, catalog: catalog, cfg: cfg, args: args}
+}
+
+// run reloads on SIGHUP and, when enabled, on changes to the configuration
+// file. It never returns.
+func (r *reloader) run() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	var poll <-chan time.Time
+	var last os.FileInfo
+	if r.cfg.Server.WatchConfig && r.cfg.path != "" {
+		last, _ = os.Stat(r.cfg.path)
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+		log.Printf("Watching %s for configuration changes\n", r.cfg.path)
+	}
+
+	for {
+		select {
+		case <-hup:
+			log.Println("SIGHUP received, reloading configuration")
+		case <-poll:
+			info, err := os.Stat(r.cfg.path)
+			if err != nil || (last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size()) {
+				continue
+			}
+			last = info
+			log.Printf("%s changed, reloading configuration\n", r.cfg.path)
+		}
+		r.reload()
+	}
+}
+
+// reload re-reads the configuration and applies what can change at runtime.
+func (r *reloader) reload() {
+	fs := flag.NewFlagSet("reload", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	next, err := parseServerFlags(fs, r.args)
+	if err != nil {
+		log.Printf("Configuration not reloaded: %v\n", err)
+		return
+	}
+
+	// Keep the running value of settings that need a restart
+	restart := []struct {
+		name      string
+		old, next interface{}
+	}{
+		{"api", &r.cfg.API, &next.API},
+		{"server", &r.cfg.Server, &next.Server},
+		{"sessions", &r.cfg.Sessions, &next.Sessions},
+		{"usage", &r.cfg.Usage, &next.Usage},
+	}
+	for _, section := range restart {
+		if !reflect.DeepEqual(section.old, section.next) {
+			log.Printf("Configuration section %q changed; restart the server to apply it\n", section.name)
+			reflect.ValueOf(section.next).Elem().Set(reflect.ValueOf(section.old).Elem())
+		}
+	}
+
+	// Reopen the log file even if unchanged, in case it was rotated
+	setLogFile(next.Logging.File)
+
+	// Replacing the limiter, breakers or cache loses their state, so keep
+	// them when their settings are unchanged
+	limiter, breakers, client := inboundLimiter.Load(), upstreamBreakers.Load(), upstreamClient.Load()
+	next.applyReloadable()
+	if next.RateLimit == r.cfg.RateLimit {
+		inboundLimiter.Store(limiter)
+	} else {
+		log.Println("Rate limits updated")
+	}
+	if next.Breaker == r.cfg.Breaker {
+		upstreamBreakers.Store(breakers)
+	} else {
+		log.Println("Circuit breaker settings updated")
+	}
+	if next.Cache == r.cfg.Cache {
+		upstreamClient.Store(client)
+	} else {
+		log.Println("Response cache settings updated; cached results were dropped")
+	}
+
+	added, removed := syncTools(r.s, r.catalog, next.Tools.allows)
+	if added > 0 || removed > 0 {
+		log.Printf("Tools updated: %d added, %d removed\n", added, removed)
+	}
+
+	r.cfg = next
+	log.Println("Configuration reloaded")
+}
+
+// syncTools makes the server offer exactly the tools of registerAllTools
+// that allows selects, and refreshes its catalog and workflow prompts to
+// match. Changing the tool list notifies connected clients.
+func syncTools(s *server.MCPServer, catalog *toolCatalog, allows func(name string) bool) (added, removed int) {
+	// Register every tool on a scratch server, with a scratch catalog
+	all := server.NewMCPServer(serverName, serverVersion)
+	allCatalog := registerAllTools(all)
+	available := all.ListTools()
+
+	current := s.ListTools()
+	var add []server.ServerTool
+	for name, tool := range available {
+		if _, ok := current[name]; !ok && allows(name) {
+			add = append(add, *tool)
+		}
+	}
+	var remove []string
+	for name := range current {
+		if _, ok := available[name]; !ok || !allows(name) {
+			remove = append(remove, name)
+		}
+	}
+
+	// Drop the entries of tools no longer offered before removing them, and
+	// add the entries of new tools after adding them; catalog readers see
+	// the catalog before or after, never a tool that is not offered
+	if len(remove) > 0 {
+		catalog.remove(remove...)
+		s.DeleteTools(remove...)
+	}
+	if len(add) > 0 {
+		s.AddTools(add...)
+		allCatalog.copyTo(catalog, add)
+	}
+	if len(add) > 0 || len(remove) > 0 {
+		s.SetPrompts(offeredPrompts(s)...)
+	}
+	return len(add), len(remove)
+}
@@ -0,0 +1,159 @@
+package mcpserver
+
+import (
+	"context"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// notifiedSession is a client session that records the notifications it is
+// sent.
+type notifiedSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (s *notifiedSession) Initialize()       {}
+func (s *notifiedSession) Initialized() bool { return true }
+func (s *notifiedSession) SessionID() string { return "reload-test" }
+func (s *notifiedSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return s.notifications
+}
+
+// methods drains the notifications received so far and returns their
+// methods.
+func (s *notifiedSession) methods() map[string]bool {
+	methods := map[string]bool{}
+	for {
+		select {
+		case n := <-s.notifications:
+			methods[n.Method] = true
+		default:
+			return methods
+		}
+	}
+}
+
+// resetReloadable turns the reloadable settings off when the test ends.
+func resetReloadable(t *testing.T) {
+	t.Cleanup(func() {
+		inboundLimiter.Store(nil)
+		upstreamBreakers.Store(nil)
+		upstreamClient.Store(nil)
+		creditGuard.Store(false)
+		creditGuardFailClosed.Store(false)
+		sessionBudget.Store(0)
+	})
+}
+
+// newTestServer builds a server offering every tool.
+func newTestServer() *server.MCPServer {
+	s, _ := buildServer(toolFilter{}, nil)
+	return s
+}
+
+// cataloged reports whether catalog has an entry for the named tool.
+func cataloged(catalog *toolCatalog, name string) bool {
+	for _, e := range catalog.list() {
+		if e.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestSyncTools(t *testing.T) {
+	resetReloadable(t)
+	s, catalog := buildServer(toolFilter{}, nil)
+	session := &notifiedSession{notifications: make(chan mcp.JSONRPCNotification, 10)}
+	if err := s.RegisterSession(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+
+	excluded := toolFilter{Exclude: []string{"interzoid_org_match_score"}}
+	if added, removed := syncTools(s, catalog, excluded.allows); added != 0 || removed != 1 {
+		t.Errorf("excluding a tool: added %d, removed %d", added, removed)
+	}
+	if s.GetTool("interzoid_org_match_score") != nil {
+		t.Error("excluded tool is still offered")
+	}
+	if cataloged(catalog, "interzoid_org_match_score") {
+		t.Error("excluded tool is still in the catalog")
+	}
+	methods := session.methods()
+	if !methods[mcp.MethodNotificationToolsListChanged] || !methods[mcp.MethodNotificationPromptsListChanged] {
+		t.Errorf("notifications = %v, want tools and prompts list_changed", methods)
+	}
+
+	if added, removed := syncTools(s, catalog, toolFilter{}.allows); added != 1 || removed != 0 {
+		t.Errorf("including it again: added %d, removed %d", added, removed)
+	}
+	if s.GetTool("interzoid_org_match_score") == nil || !cataloged(catalog, "interzoid_org_match_score") {
+		t.Error("included tool is not offered and cataloged")
+	}
+	if !session.methods()[mcp.MethodNotificationToolsListChanged] {
+		t.Error("adding a tool sent no tools/list_changed notification")
+	}
+
+	// Nothing changed, so nothing is sent
+	syncTools(s, catalog, toolFilter{}.allows)
+	if methods := session.methods(); len(methods) != 0 {
+		t.Errorf("an unchanged tool list sent %v", methods)
+	}
+}
+
+func TestReload(t *testing.T) {
+	resetReloadable(t)
+	path := writeConfig(t, `
+server:
+  port: "8080"
+rate_limit:
+  per_minute: 60
+`)
+	args := []string{"-config", path}
+	cfg, err := parseServerFlags(flag.NewFlagSet("test", flag.ContinueOnError), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.applyReloadable()
+	s, catalog := buildServer(cfg.Tools, nil)
+	r := newReloader(s, catalog, cfg, args)
+	breakers := upstreamBreakers.Load()
+
+	if err := os.WriteFile(path, []byte(`
+server:
+  port: "9090"
+rate_limit:
+  per_minute: 120
+tools:
+  exclude: ["interzoid_gender"]
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r.reload()
+
+	if l := inboundLimiter.Load(); l == nil || l.rate != 2 {
+		t.Errorf("rate limiter = %+v, want 120 calls a minute", l)
+	}
+	if upstreamBreakers.Load() != breakers {
+		t.Error("unchanged breaker settings replaced the breakers")
+	}
+	if s.GetTool("interzoid_gender") != nil || cataloged(catalog, "interzoid_gender") {
+		t.Error("tool excluded by the reload is still offered or cataloged")
+	}
+	if r.cfg.Server.Port != "8080" {
+		t.Errorf("server.port = %q, want the running value kept until a restart", r.cfg.Server.Port)
+	}
+
+	// An invalid file leaves the running configuration alone
+	if err := os.WriteFile(path, []byte("rate_limit:\n  per_minute: -1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r.reload()
+	if r.cfg.RateLimit.PerMinute != 120 {
+		t.Errorf("rate_limit.per_minute = %v after an invalid reload", r.cfg.RateLimit.PerMinute)
+	}
+}
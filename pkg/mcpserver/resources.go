@@ -0,0 +1,233 @@
+package mcpserver
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============================================================================
+// MCP RESOURCES
+// ============================================================================
+//
+// Machine-readable descriptions of the tool catalog and its pricing, so
+// agents do not have to infer cost from tool description text.
+// ============================================================================
+
+const (
+	catalogURI      = "interzoid://catalog"
+	pricingURI      = "interzoid://pricing"
+	x402ManifestURI = "interzoid://x402-manifest"
+
+	// x402ManifestPath is where Interzoid publishes its x402 discovery manifest
+	x402ManifestPath = "/.well-known/x402.json"
+
+	// x402ManifestTTL is how often the manifest is re-fetched from upstream
+	x402ManifestTTL = time.Hour
+
+	// x402ManifestRetry is how soon a failed fetch of the manifest is retried
+	x402ManifestRetry = 30 * time.Second
+
+	// bundledX402ManifestPath is the bundled copy of the upstream manifest
+	bundledX402ManifestPath = "x402/x402.json"
+)
+
+// bundledX402Manifest holds a copy of the upstream manifest, served until
+// the server has fetched a current one. The copy is written by go generate
+// (which needs network access) and is only embedded when present, so
+// builds without it serve the manifest from upstream alone.
+//
+//go:generate curl -fsSL -o x402/x402.json https://api.interzoid.com/.well-known/x402.json
+//go:embed x402
+var bundledX402Manifest embed.FS
+
+// registerResources registers the catalog, pricing and x402 manifest
+// resources, which describe the tools in catalog.
+func registerResources(s *server.MCPServer, catalog *toolCatalog) {
+	s.AddResource(
+		mcp.NewResource(catalogURI, "Interzoid tool catalog",
+			mcp.WithResourceDescription("Every Interzoid tool with its category, API endpoint, parameters (including the API query parameter names), price tier, USDC price and credit cost."),
+			mcp.WithMIMEType("application/json"),
+		),
+		jsonResourceHandler(func(ctx context.Context) (interface{}, error) {
+			return catalog.list(), nil
+		}),
+	)
+
+	s.AddResource(
+		mcp.NewResource(pricingURI, "Interzoid pricing",
+			mcp.WithResourceDescription("Price tiers for Interzoid APIs: x402 price in USDC (atomic units and dollars), credit cost with an API key, and the tools in each tier."),
+			mcp.WithMIMEType("application/json"),
+		),
+		jsonResourceHandler(func(ctx context.Context) (interface{}, error) {
+			return pricingDocument(catalog), nil
+		}),
+	)
+
+	s.AddResource(
+		mcp.NewResource(x402ManifestURI, "Interzoid x402 manifest",
+			mcp.WithResourceDescription("The x402 discovery manifest published at https://api.interzoid.com/.well-known/x402.json, refreshed hourly when the server is online. Falls back to a copy bundled with the server, when it was built with one."),
+			mcp.WithMIMEType("application/json"),
+		),
+		func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+			manifest, err := x402Manifests.get(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return []mcp.ResourceContents{
+				mcp.TextResourceContents{
+					URI:      request.Params.URI,
+					MIMEType: "application/json",
+					Text:     string(manifest),
+				},
+			}, nil
+		},
+	)
+}
+
+// jsonResourceHandler adapts a function returning a value into a resource
+// handler that serves it as indented JSON.
+func jsonResourceHandler(fn func(ctx context.Context) (interface{}, error)) server.ResourceHandlerFunc {
+	return func(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		v, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		jsonBytes, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to format resource: %w", err)
+		}
+
+		return []mcp.ResourceContents{
+			mcp.TextResourceContents{
+				URI:      request.Params.URI,
+				MIMEType: "application/json",
+				Text:     string(jsonBytes),
+			},
+		}, nil
+	}
+}
+
+// pricingTier is one tier of the pricing resource.
+type pricingTier struct {
+	Tier       string   `json:"tier"`
+	AtomicUSDC int64    `json:"priceAtomicUSDC"`
+	USD        float64  `json:"priceUSD"`
+	Credits    int64    `json:"credits"`
+	Tools      []string `json:"tools"`
+}
+
+// pricingDocument builds the pricing resource from a tool catalog.
+func pricingDocument(catalog *toolCatalog) map[string]interface{} {
+	tiers := []pricingTier{
+		{Tier: standardTier.name, AtomicUSDC: standardTier.atomicUSDC, USD: standardTier.usd(), Credits: standardTier.credits, Tools: []string{}},
+		{Tier: premiumTier.name, AtomicUSDC: premiumTier.atomicUSDC, USD: premiumTier.usd(), Credits: premiumTier.credits, Tools: []string{}},
+	}
+	for _, e := range catalog.list() {
+		for i := range tiers {
+			if tiers[i].Tier == e.PriceTier {
+				tiers[i].Tools = append(tiers[i].Tools, e.Name)
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"currency": "USDC",
+		"decimals": 6,
+		"network":  x402Network,
+		"asset":    usdcAsset,
+		"tiers":    tiers,
+	}
+}
+
+// x402Manifests caches the upstream x402 manifest.
+var x402Manifests = &manifestCache{}
+
+type manifestCache struct {
+	mu       sync.Mutex
+	body     []byte
+	err      error
+	next     time.Time // earliest time of the next fetch
+	fetching bool
+}
+
+// get returns the upstream manifest, re-fetching it at most once per
+// x402ManifestTTL, or x402ManifestRetry after a failed fetch. When upstream
+// is unreachable it serves the last fetched copy, or the bundled manifest if
+// there is none. Only one reader fetches, without the reader's cancellation
+// since the result is shared; the others serve the copy they have rather
+// than wait on upstream.
+func (c *manifestCache) get(ctx context.Context) ([]byte, error) {
+	c.mu.Lock()
+	refresh := !c.fetching && !time.Now().Before(c.next)
+	if refresh {
+		c.fetching = true
+	}
+	body, fetchErr := c.body, c.err
+	c.mu.Unlock()
+
+	if refresh {
+		fetched, err := fetchX402Manifest(context.WithoutCancel(ctx))
+		c.mu.Lock()
+		if err == nil {
+			c.body, body = fetched, fetched
+			c.next = time.Now().Add(x402ManifestTTL)
+		} else {
+			c.next = time.Now().Add(x402ManifestRetry)
+		}
+		c.err, fetchErr = err, err
+		c.fetching = false
+		c.mu.Unlock()
+	}
+
+	if body != nil {
+		return body, nil
+	}
+	if bundled, err := fs.ReadFile(bundledX402Manifest, bundledX402ManifestPath); err == nil {
+		return bundled, nil
+	}
+	if fetchErr == nil {
+		return nil, fmt.Errorf("the x402 manifest is still being fetched from Interzoid; try again shortly")
+	}
+	return nil, fmt.Errorf("the x402 manifest is unavailable: it could not be fetched from Interzoid (%v) and none is bundled with this server", fetchErr)
+}
+
+// fetchX402Manifest downloads the manifest from the Interzoid API.
+func fetchX402Manifest(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", interzoidBaseURL+x402ManifestPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("manifest request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("manifest request returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("manifest is not valid JSON")
+	}
+	return body, nil
+}
@@ -0,0 +1,263 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// readResource reads uri from a server with every tool and resource
+// registered, returning the text contents or the JSON-RPC error message.
+func readResource(t *testing.T, uri string) (string, string) {
+	t.Helper()
+	s := server.NewMCPServer(serverName, serverVersion, server.WithResourceCapabilities(false, false))
+	registerResources(s, registerAllTools(s))
+
+	message, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "resources/read",
+		"params":  map[string]string{"uri": uri},
+	})
+	switch response := s.HandleMessage(context.Background(), message).(type) {
+	case mcp.JSONRPCResponse:
+		result := response.Result.(mcp.ReadResourceResult)
+		return result.Contents[0].(mcp.TextResourceContents).Text, ""
+	case mcp.JSONRPCError:
+		return "", response.Error.Message
+	default:
+		t.Fatalf("unexpected response %#v", response)
+		return "", ""
+	}
+}
+
+func TestCatalogResource(t *testing.T) {
+	text, errMsg := readResource(t, catalogURI)
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	}
+	var entries []catalogEntry
+	if err := json.Unmarshal([]byte(text), &entries); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := make(map[string]catalogEntry)
+	for _, e := range entries {
+		byName[e.Name] = e
+	}
+	business := byName["interzoid_business_info"]
+	if business.Endpoint != "/getbusinessinfo" || business.PriceTier != premiumTier.name || business.Credits != premiumTier.credits {
+		t.Errorf("business info entry = %+v", business)
+	}
+	company, ok := byName["interzoid_company_match_advanced"]
+	if !ok || company.Category != catMatching || len(company.Parameters) == 0 || !company.Parameters[0].Required {
+		t.Errorf("company match entry = %+v", company)
+	}
+	if entries[0].Category != catMatching || entries[len(entries)-1].Category != catUtility {
+		t.Errorf("entries not in category order: first %s, last %s", entries[0].Category, entries[len(entries)-1].Category)
+	}
+}
+
+func TestPricingResource(t *testing.T) {
+	text, errMsg := readResource(t, pricingURI)
+	if errMsg != "" {
+		t.Fatal(errMsg)
+	}
+	var doc struct {
+		Network string        `json:"network"`
+		Tiers   []pricingTier `json:"tiers"`
+	}
+	if err := json.Unmarshal([]byte(text), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.Network != x402Network || len(doc.Tiers) != 2 {
+		t.Fatalf("pricing = %s", text)
+	}
+	for _, tier := range doc.Tiers {
+		for _, name := range tier.Tools {
+			if got := tierForEndpoint(mustCatalogEndpoint(t, name)).name; got != tier.Tier {
+				t.Errorf("%s listed in tier %s, priced as %s", name, tier.Tier, got)
+			}
+		}
+	}
+}
+
+func mustCatalogEndpoint(t *testing.T, name string) string {
+	t.Helper()
+	catalog := registerAllTools(server.NewMCPServer(serverName, serverVersion))
+	for _, e := range catalog.list() {
+		if e.Name == name {
+			return e.Endpoint
+		}
+	}
+	t.Fatalf("%s not in the catalog", name)
+	return ""
+}
+
+// withManifestCache gives the test an empty x402 manifest cache.
+func withManifestCache(t *testing.T) {
+	previous := x402Manifests
+	x402Manifests = &manifestCache{}
+	t.Cleanup(func() { x402Manifests = previous })
+}
+
+func TestX402ManifestResource(t *testing.T) {
+	withManifestCache(t)
+	const manifest = `{"x402Version":1,"accepts":[{"payTo":"0xabc","maxTimeoutSeconds":60}]}`
+	calls := stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != x402ManifestPath {
+			t.Errorf("fetched %s", r.URL.Path)
+		}
+		w.Write([]byte(manifest))
+	})
+
+	for i := 0; i < 3; i++ {
+		text, errMsg := readResource(t, x402ManifestURI)
+		if errMsg != "" || text != manifest {
+			t.Fatalf("read %d = %q, %q, want the upstream manifest", i, text, errMsg)
+		}
+	}
+	if calls() != 1 {
+		t.Errorf("upstream fetches = %d, want 1 within the TTL", calls())
+	}
+
+	// Once the TTL has passed a failed refresh keeps serving the last copy
+	stubUpstream(t, unavailable)
+	x402Manifests.next = time.Now()
+	if text, errMsg := readResource(t, x402ManifestURI); text != manifest {
+		t.Errorf("read after a failed refresh = %q, %q, want the cached manifest", text, errMsg)
+	}
+}
+
+// A failed fetch is retried after x402ManifestRetry rather than the TTL, and
+// a reader that gives up does not cancel the shared fetch.
+func TestX402ManifestRetry(t *testing.T) {
+	withManifestCache(t)
+	stubUpstream(t, unavailable)
+	x402Manifests.get(context.Background())
+	if wait := time.Until(x402Manifests.next); wait > x402ManifestRetry {
+		t.Errorf("next fetch in %v after a failure, want at most %v", wait, x402ManifestRetry)
+	}
+
+	const manifest = `{"x402Version":1}`
+	stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(manifest))
+	})
+	x402Manifests.next = time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if body, err := x402Manifests.get(ctx); err != nil || string(body) != manifest {
+		t.Errorf("get with a canceled context = %q, %v, want the upstream manifest", body, err)
+	}
+	if wait := time.Until(x402Manifests.next); wait <= x402ManifestRetry {
+		t.Errorf("next fetch in %v after a success, want the TTL", wait)
+	}
+}
+
+// Built without a bundled copy, the resource fails rather than serve a
+// manifest the server made up.
+func TestX402ManifestUnavailable(t *testing.T) {
+	if _, err := bundledX402Manifest.Open(bundledX402ManifestPath); err == nil {
+		t.Skip("built with a bundled manifest")
+	}
+	withManifestCache(t)
+	stubUpstream(t, unavailable)
+
+	_, errMsg := readResource(t, x402ManifestURI)
+	if !strings.Contains(errMsg, "status 503") || !strings.Contains(errMsg, "none is bundled") {
+		t.Errorf("error = %q, want the fetch failure", errMsg)
+	}
+}
+
+// With Interzoid unreachable, the resource serves the bundled copy.
+func TestX402ManifestBundled(t *testing.T) {
+	bundled, err := fs.ReadFile(bundledX402Manifest, bundledX402ManifestPath)
+	if err != nil {
+		t.Fatalf("no bundled manifest at %s; run go generate ./pkg/mcpserver to fetch one", bundledX402ManifestPath)
+	}
+	if !json.Valid(bundled) {
+		t.Fatalf("%s is not valid JSON", bundledX402ManifestPath)
+	}
+	withManifestCache(t)
+	previous := httpClient.Transport
+	httpClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return nil, errors.New("network is unreachable")
+	})
+	t.Cleanup(func() { httpClient.Transport = previous })
+
+	if text, errMsg := readResource(t, x402ManifestURI); errMsg != "" || text != string(bundled) {
+		t.Errorf("read = %.80q, %q, want the bundled manifest", text, errMsg)
+	}
+}
+
+// Concurrent readers share a single upstream fetch.
+func TestX402ManifestSingleFetch(t *testing.T) {
+	withManifestCache(t)
+	release := make(chan struct{})
+	calls := stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{}`))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			x402Manifests.get(context.Background())
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls() != 1 {
+		t.Errorf("upstream fetches = %d, want 1", calls())
+	}
+}
+
+// Every tool carries a title, behavior hints and price metadata that match
+// its catalog entry.
+func TestToolAnnotations(t *testing.T) {
+	s := server.NewMCPServer(serverName, serverVersion)
+	registerAllTools(s)
+
+	tools := s.ListTools()
+	if len(tools) == 0 {
+		t.Fatal("no tools registered")
+	}
+	for name, tool := range tools {
+		a := tool.Tool.Annotations
+		if a.Title == "" {
+			t.Errorf("%s has no title", name)
+		}
+		if a.ReadOnlyHint == nil || !*a.ReadOnlyHint || a.IdempotentHint == nil || a.OpenWorldHint == nil {
+			t.Errorf("%s hints = %+v", name, a)
+		} else if priced := toolTier(tool.Tool).atomicUSDC > 0; *a.IdempotentHint == priced {
+			t.Errorf("%s idempotentHint = %v, want %v", name, *a.IdempotentHint, !priced)
+		}
+		if tool.Tool.Meta == nil {
+			t.Errorf("%s has no _meta", name)
+			continue
+		}
+		price, _ := tool.Tool.Meta.AdditionalFields["com.interzoid/price"].(map[string]any)
+		if price == nil || price["tier"] == "" {
+			t.Errorf("%s _meta = %v", name, tool.Tool.Meta.AdditionalFields)
+		}
+	}
+
+	meta := tools["interzoid_business_info"].Tool.Meta.AdditionalFields
+	price := meta["com.interzoid/price"].(map[string]any)
+	if meta["com.interzoid/category"] != catEnrichment || price["tier"] != premiumTier.name || price["priceAtomicUSDC"] != premiumTier.atomicUSDC {
+		t.Errorf("business info _meta = %v", meta)
+	}
+}
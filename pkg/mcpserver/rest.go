@@ -0,0 +1,269 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============================================================================
+// REST GATEWAY
+// ============================================================================
+//
+// For services that do not speak MCP, every tool is also served as
+//
+//   POST /v1/tools/{name}   body: the tool's arguments as a JSON object
+//
+// Calls go through MCPServer.HandleMessage, exactly as a tools/call over
+// MCP would, so they share the tool handlers and middleware, the API key
+// from the Authorization header, caller identification, rate limits, the
+// credit guard and the usage ledger. Each caller's calls share one session
+// (see restSession), so the session budget applies to them too.
+// GET /v1/openapi.json describes the gateway, generated from the registered
+// tools.
+//
+// Responses:
+//   200  the tool's JSON result
+//   402  no API key was sent; the body is the x402 payment_required result
+//        with the payment requirements
+//   400  the body is not a JSON object
+//   404  no such tool
+//   4xx/5xx  the tool failed; the body is its error (see errors.go), and
+//            the status follows the error code: 422 invalid_input,
+//            401 auth_failed, 402 out_of_credits and payment_required,
+//            403 budget_exceeded, 429 rate_limited (with Retry-After),
+//            503 upstream_unavailable, 504 timeout, 502 malformed_response,
+//            500 internal_error
+// ============================================================================
+
+const (
+	restToolsPath   = "/v1/tools/"
+	restOpenAPIPath = "/v1/openapi.json"
+
+	// restMaxBodySize bounds a request body
+	restMaxBodySize = 1 << 20
+)
+
+// addRESTGateway adds the REST gateway to mux. contextFunc records who is
+// calling in the request context, as for the MCP transports.
+func addRESTGateway(mux *http.ServeMux, s *server.MCPServer, contextFunc server.HTTPContextFunc) {
+	mux.HandleFunc("POST "+restToolsPath+"{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if s.GetTool(name) == nil {
+			writeRESTError(w, http.StatusNotFound, fmt.Sprintf("Unknown tool: %s", name))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, restMaxBodySize))
+		if err != nil {
+			writeRESTError(w, http.StatusBadRequest, "Failed to read request body: "+err.Error())
+			return
+		}
+		var args map[string]interface{}
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &args); err != nil || args == nil {
+				writeRESTError(w, http.StatusBadRequest, "Request body must be a JSON object of tool arguments")
+				return
+			}
+		}
+
+		// Headers reach the handler through withConnectionHeaders, as on
+		// the WebSocket transport
+		ctx := contextFunc(r.Context(), r)
+		ctx = context.WithValue(ctx, connHeaderKey, r.Header)
+		ctx = s.WithContext(ctx, restSession(ctx, r.Header))
+
+		result, err := callTool(ctx, s, name, args)
+		if err != nil {
+			writeRESTError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		text := resultText(result)
+		if result.IsError {
+			body := parseToolError(text)
+			if body.RetryAfterSeconds > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(body.RetryAfterSeconds))
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(restErrorStatus[body.Code])
+			writeJSON(w, body)
+			return
+		}
+		// An x402 payment_required answer is a result, not a tool error,
+		// but the lookup was not made
+		var answer map[string]interface{}
+		w.Header().Set("Content-Type", "application/json")
+		if json.Unmarshal([]byte(text), &answer) == nil && paymentRequired(answer) {
+			w.WriteHeader(http.StatusPaymentRequired)
+		}
+		io.WriteString(w, text)
+	})
+
+	mux.HandleFunc("GET "+restOpenAPIPath, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, restOpenAPIDocument(s))
+	})
+}
+
+// restErrorStatus is the HTTP status for each tool error code.
+var restErrorStatus = map[errorCode]int{
+	codeInvalidInput:        http.StatusUnprocessableEntity,
+	codeAuthFailed:          http.StatusUnauthorized,
+	codeOutOfCredits:        http.StatusPaymentRequired,
+	codePaymentRequired:     http.StatusPaymentRequired,
+	codeBudgetExceeded:      http.StatusForbidden,
+	codeRateLimited:         http.StatusTooManyRequests,
+	codeUpstreamUnavailable: http.StatusServiceUnavailable,
+	codeTimeout:             http.StatusGatewayTimeout,
+	codeMalformedResponse:   http.StatusBadGateway,
+	codeInternal:            http.StatusInternalServerError,
+}
+
+// restErrorCodes lists the tool error codes, sorted.
+func restErrorCodes() []string {
+	codes := make([]string, 0, len(restErrorStatus))
+	for code := range restErrorStatus {
+		codes = append(codes, string(code))
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// restSession returns the session a REST call is made in. REST callers hold
+// no MCP session, so each caller (by API key, or by address without one) is
+// given its own, and its spend is kept until it has been idle for
+// sessionTTL.
+func restSession(ctx context.Context, header http.Header) runSession {
+	return runSession{id: "rest:" + callerID(ctx, mcp.CallToolRequest{Header: header})}
+}
+
+// writeRESTError writes a JSON error body.
+func writeRESTError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	writeJSON(w, map[string]string{"error": message})
+}
+
+// restOpenAPIDocument describes the REST gateway. Each tool's request body
+// schema is its MCP input schema, and its operation ID is the tool name.
+func restOpenAPIDocument(s *server.MCPServer) map[string]interface{} {
+	tools := s.ListTools()
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errorResponse := func(description string) map[string]interface{} {
+		return map[string]interface{}{
+			"description": description,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{
+					"schema": map[string]interface{}{"$ref": "#/components/schemas/Error"},
+				},
+			},
+		}
+	}
+
+	paths := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		tool := tools[name].Tool
+		op := map[string]interface{}{
+			"operationId": name,
+			"summary":     tool.Annotations.Title,
+			"description": tool.Description,
+			"requestBody": map[string]interface{}{
+				"required": len(tool.InputSchema.Required) > 0,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": tool.InputSchema},
+				},
+			},
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{
+					"description": "The tool's result",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{
+							"schema": map[string]interface{}{"type": "object"},
+						},
+					},
+				},
+				"400": errorResponse("The request body is not a JSON object"),
+				"401": errorResponse("auth_failed: the API key was rejected, or the tool needs one"),
+				"402": map[string]interface{}{
+					"description": "out_of_credits, or payment_required: with no API key, the body is the x402 payment_required result holding the payment requirements",
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{
+							"schema": map[string]interface{}{"oneOf": []interface{}{
+								map[string]interface{}{"$ref": "#/components/schemas/Error"},
+								map[string]interface{}{"type": "object"},
+							}},
+						},
+					},
+				},
+				"403": errorResponse("budget_exceeded: the session has spent its budget"),
+				"422": errorResponse("invalid_input: the arguments are invalid"),
+				"429": errorResponse("rate_limited; retry after the number of seconds in Retry-After"),
+				"502": errorResponse("malformed_response: the Interzoid response could not be read"),
+				"503": errorResponse("upstream_unavailable: the Interzoid API is unavailable"),
+				"504": errorResponse("timeout: the Interzoid API did not answer in time"),
+			},
+		}
+		if tool.Meta != nil {
+			if category, ok := tool.Meta.AdditionalFields["com.interzoid/category"].(string); ok {
+				op["tags"] = []string{category}
+			}
+			if price, ok := tool.Meta.AdditionalFields["com.interzoid/price"]; ok {
+				op["x-interzoid-price"] = price
+			}
+		}
+		paths[restToolsPath+name] = map[string]interface{}{"post": op}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":       "Interzoid MCP Server REST Gateway",
+			"version":     serverVersion,
+			"description": "Every tool of the Interzoid MCP server as a plain HTTP endpoint. Send the tool's arguments as a JSON object; the result is the JSON an MCP client would receive. Without an API key, calls use x402 USDC micropayments.",
+		},
+		"paths": paths,
+		"security": []map[string]interface{}{
+			{"bearerAuth": []string{}},
+			{}, // x402: no key, pay per call
+		},
+		"components": map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "Interzoid API key",
+				},
+			},
+			"schemas": map[string]interface{}{
+				"Error": map[string]interface{}{
+					"type":     "object",
+					"required": []string{"error"},
+					"properties": map[string]interface{}{
+						"error": map[string]interface{}{"type": "string"},
+						"code": map[string]interface{}{
+							"type":        "string",
+							"description": "Stable error code; absent for errors of the gateway itself",
+							"enum":        restErrorCodes(),
+						},
+						"hint":              map[string]interface{}{"type": "string"},
+						"retryable":         map[string]interface{}{"type": "boolean"},
+						"retryAfterSeconds": map[string]interface{}{"type": "integer"},
+						"upstreamStatus":    map[string]interface{}{"type": "integer"},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,194 @@
+package mcpserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func restServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	s := server.NewMCPServer(serverName, serverVersion, server.WithToolHandlerMiddleware(withConnectionHeaders))
+	registerAllTools(s)
+	ts := httptest.NewServer(newHTTPMux(s, "http", false, true, httpContextFunc))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func restPost(t *testing.T, ts *httptest.Server, tool, body string, header http.Header) (*http.Response, string) {
+	t.Helper()
+	req, _ := http.NewRequest("POST", ts.URL+restToolsPath+tool, strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, string(data)
+}
+
+func TestRESTGateway(t *testing.T) {
+	t.Setenv("INTERZOID_API_KEY", "")
+	var apiKey string
+	stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("x-api-key")
+		w.Write([]byte(`{"Gender":"F","Code":"Success"}`))
+	})
+	ts := restServer(t)
+
+	tests := []struct {
+		name, tool, body string
+		status           int
+		want             string
+	}{
+		{"result", "interzoid_gender", `{"name":"Maria"}`, http.StatusOK, `"Gender": "F"`},
+		{"unknown tool", "interzoid_nope", `{}`, http.StatusNotFound, `Unknown tool: interzoid_nope`},
+		{"array body", "interzoid_gender", `["Maria"]`, http.StatusBadRequest, `JSON object`},
+		{"null body", "interzoid_gender", `null`, http.StatusBadRequest, `JSON object`},
+		{"invalid input", "interzoid_gender", `{}`, http.StatusUnprocessableEntity, `invalid_input`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := restPost(t, ts, tt.tool, tt.body, http.Header{"Authorization": {"Bearer rest-key"}})
+			if resp.StatusCode != tt.status || !strings.Contains(body, tt.want) {
+				t.Errorf("status %d body %s, want %d containing %q", resp.StatusCode, body, tt.status, tt.want)
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+		})
+	}
+	if apiKey != "rest-key" {
+		t.Errorf("upstream API key = %q, want the Authorization header's", apiKey)
+	}
+}
+
+func TestRESTGatewayRateLimit(t *testing.T) {
+	previous := inboundLimiter.Load()
+	inboundLimiter.Store(newRateLimiter(1, 1, false))
+	t.Cleanup(func() { inboundLimiter.Store(previous) })
+	stubUpstream(t, answer(`{"Gender":"F","Code":"Success"}`))
+	ts := restServer(t)
+
+	if resp, body := restPost(t, ts, "interzoid_gender", `{"name":"Maria"}`, nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("first call = %d %s", resp.StatusCode, body)
+	}
+	resp, body := restPost(t, ts, "interzoid_gender", `{"name":"Maria"}`, nil)
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" || !strings.Contains(body, "retry after") {
+		t.Errorf("rate limited call = %d (Retry-After %q) %s", resp.StatusCode, resp.Header.Get("Retry-After"), body)
+	}
+}
+
+// Each REST caller has its own session budget.
+func TestRESTGatewaySessionBudget(t *testing.T) {
+	withSessionBudget(t, standardTier.atomicUSDC)
+	stubUpstream(t, answer(`{"Gender":"F","Code":"Success"}`))
+	ts := restServer(t)
+	call := func(key string) int {
+		resp, _ := restPost(t, ts, "interzoid_gender", `{"name":"Maria"}`, http.Header{"Authorization": {"Bearer " + key}})
+		return resp.StatusCode
+	}
+
+	if status := call("key-a"); status != http.StatusOK {
+		t.Fatalf("first call = %d", status)
+	}
+	if status := call("key-a"); status != http.StatusForbidden {
+		t.Errorf("call over budget = %d, want 403", status)
+	}
+	if status := call("key-b"); status != http.StatusOK {
+		t.Errorf("another caller's call = %d, want 200", status)
+	}
+}
+
+// A failed call answers with the status of its error code and the error body.
+// An x402 payment_required answer is served as 402 with the payment
+// requirements.
+func TestRESTGatewayPaymentRequired(t *testing.T) {
+	t.Setenv("INTERZOID_API_KEY", "")
+	stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusPaymentRequired)
+		io.WriteString(w, `{"x402Version":1,"accepts":[{"scheme":"exact"}]}`)
+	})
+	ts := restServer(t)
+
+	resp, body := restPost(t, ts, "interzoid_gender", `{"name":"Maria"}`, nil)
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatalf("body %s: %v", body, err)
+	}
+	if resp.StatusCode != http.StatusPaymentRequired || !paymentRequired(got) || got["paymentRequirements"] == nil {
+		t.Errorf("status %d body %s, want 402 with the payment requirements", resp.StatusCode, body)
+	}
+}
+
+func TestRESTGatewayErrorStatus(t *testing.T) {
+	stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		io.WriteString(w, `{"Code":"Fail","Message":"invalid license key"}`)
+	})
+	ts := restServer(t)
+
+	resp, body := restPost(t, ts, "interzoid_gender", `{"name":"Maria"}`, http.Header{"Authorization": {"Bearer bad-key"}})
+	var got toolErrorBody
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatalf("body %s: %v", body, err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized || got.Code != codeAuthFailed || got.UpstreamStatus != http.StatusForbidden || got.Hint == "" {
+		t.Errorf("status %d body %+v, want 401 auth_failed from upstream 403", resp.StatusCode, got)
+	}
+}
+
+func TestRESTOpenAPI(t *testing.T) {
+	ts := restServer(t)
+	resp, err := http.Get(ts.URL + restOpenAPIPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var doc struct {
+		Paths map[string]struct {
+			Post struct {
+				OperationID string `json:"operationId"`
+				RequestBody struct {
+					Content map[string]struct {
+						Schema struct {
+							Required []string
+						}
+					}
+				} `json:"requestBody"`
+			}
+		}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+
+	s := server.NewMCPServer(serverName, serverVersion)
+	registerAllTools(s)
+	if len(doc.Paths) != len(s.ListTools()) {
+		t.Errorf("%d paths for %d tools", len(doc.Paths), len(s.ListTools()))
+	}
+	op := doc.Paths[restToolsPath+"interzoid_org_match_score"].Post
+	if op.OperationID != "interzoid_org_match_score" || len(op.RequestBody.Content["application/json"].Schema.Required) != 2 {
+		t.Errorf("interzoid_org_match_score operation = %+v", op)
+	}
+}
+
+// The gateway is only served when enabled.
+func TestRESTGatewayDisabled(t *testing.T) {
+	ts := httptest.NewServer(newHTTPMux(server.NewMCPServer(serverName, serverVersion), "http", false, false, httpContextFunc))
+	defer ts.Close()
+	resp, body := restPost(t, ts, "interzoid_gender", `{}`, nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("gateway disabled: %d %s", resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,164 @@
+// Package mcpserver is the Interzoid MCP server: every Interzoid API as an
+// MCP tool, with the resources, prompts, transports and subcommands of the
+// interzoid-mcp-server command, which is a thin wrapper around Main.
+//
+// Other Go programs can embed the tools with NewServer (see embed.go).
+package mcpserver
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	serverName    = "Interzoid Data Quality APIs"
+	serverVersion = "1.0.0"
+)
+
+// Main runs the interzoid-mcp-server command with the given arguments
+// (without the program name) and returns its exit status.
+func Main(args []string) int {
+	// Subcommands
+	if len(args) > 0 {
+		switch args[0] {
+		case "usage":
+			return runUsageCommand(args[1:])
+		case "call":
+			return runCallCommand(args[1:])
+		case "pipe":
+			return runPipeCommand(args[1:])
+		case "catalog":
+			return runCatalogCommand(args[1:])
+		case "config":
+			return runConfigCommand(args[1:])
+		}
+	}
+
+	// Configuration file and environment, then CLI flags
+	cfg, err := parseServerFlags(flag.CommandLine, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer cfg.apply()()
+	publishMetrics()
+
+	if cfg.Sessions.Store != "" {
+		store, err := openSQLiteSessionStore(cfg.Sessions.Store)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Session store error: %v\n", err)
+			return 1
+		}
+		defer store.close()
+		sessions = store
+		httpSessions = storeSessionIdManager{store}
+	}
+	if cfg.Sessions.Stateless {
+		httpSessions = &server.StatelessSessionIdManager{}
+	}
+
+	s, catalog := buildServer(cfg.Tools, nil)
+
+	// Reload the configuration and tool list on SIGHUP, or when the
+	// configuration file changes if -watch-config is set
+	go newReloader(s, catalog, cfg, args).run()
+
+	switch cfg.Server.Transport {
+	case "stdio":
+		log.Println("Starting Interzoid MCP server (stdio transport)...")
+		if err := server.ServeStdio(s); err != nil {
+			fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
+			return 1
+		}
+
+	case "http", "sse", "combined", "websocket":
+		addr := ":" + cfg.Server.Port
+		log.Printf("Starting Interzoid MCP server (%s) on %s...\n", transportNames[cfg.Server.Transport], addr)
+		logHTTPEndpoints(addr, cfg.Server.Transport, cfg.Server.Metrics, cfg.Server.REST)
+
+		if err := http.ListenAndServe(addr, newHTTPMux(s, cfg.Server.Transport, cfg.Server.Metrics, cfg.Server.REST, httpContextFunc)); err != nil {
+			fmt.Fprintf(os.Stderr, "HTTP server error: %v\n", err)
+			return 1
+		}
+
+	case "unix":
+		// validate has checked that the mode is octal
+		mode, _ := strconv.ParseUint(cfg.Server.SocketMode, 8, 32)
+		log.Printf("Starting Interzoid MCP server (unix transport) on %s (mode %04o)...\n", cfg.Server.Socket, mode)
+		log.Printf("MCP endpoints %s, %s and %s are served over the socket\n", mcpPath, ssePath, messagePath)
+
+		if err := serveUnix(s, cfg.Server.Socket, os.FileMode(mode), cfg.Server.Metrics, cfg.Server.REST); err != nil {
+			fmt.Fprintf(os.Stderr, "Unix socket server error: %v\n", err)
+			return 1
+		}
+
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown transport: %s (use 'stdio', 'http', 'sse', 'combined', 'websocket' or 'unix')\n", cfg.Server.Transport)
+		return 1
+	}
+	return 0
+}
+
+// parseServerFlags loads the configuration and applies the server's
+// command-line flags over it. Reloads call it again with the original
+// arguments, so flags keep overriding the file.
+func parseServerFlags(fs *flag.FlagSet, args []string) (*config, error) {
+	cfg, err := loadConfigFlag(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	fs.StringVar(&cfg.Server.Transport, "transport", cfg.Server.Transport, "Transport type: stdio, http (Streamable HTTP), sse (HTTP+SSE), combined (both), websocket or unix")
+	fs.StringVar(&cfg.Server.Port, "port", cfg.Server.Port, "Port for the HTTP and WebSocket transports")
+	fs.StringVar(&cfg.Server.Socket, "socket", cfg.Server.Socket, "Socket path for the unix transport")
+	fs.StringVar(&cfg.Server.SocketMode, "socket-mode", cfg.Server.SocketMode, "File permissions (octal) of the unix transport socket")
+	addCallFlags(fs, cfg)
+	fs.BoolVar(&cfg.Server.TrustProxy, "trust-proxy", cfg.Server.TrustProxy, "Use X-Forwarded-For / X-Real-IP to identify HTTP clients")
+	fs.BoolVar(&cfg.Server.Metrics, "metrics", cfg.Server.Metrics, "Serve expvar metrics at /debug/vars (HTTP transports)")
+	fs.BoolVar(&cfg.Server.REST, "rest", cfg.Server.REST, "Also serve every tool as a REST endpoint at /v1/tools/{name} (HTTP transports)")
+	fs.BoolVar(&cfg.Server.WatchConfig, "watch-config", cfg.Server.WatchConfig, "Reload the configuration file when it changes (SIGHUP always reloads)")
+	fs.BoolVar(&cfg.Sessions.Stateless, "stateless", cfg.Sessions.Stateless, "Do not issue Streamable HTTP session IDs, so any replica can serve any request")
+	fs.StringVar(&cfg.Sessions.Store, "session-store", cfg.Sessions.Store, "SQLite file shared by replicas for Streamable HTTP sessions and stream events (empty keeps them in memory)")
+	fs.DurationVar(&cfg.Sessions.StreamRetention, "stream-retention", cfg.Sessions.StreamRetention, "How long events of a finished Streamable HTTP stream are kept for clients to resume")
+	addBudgetFlag(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return cfg, cfg.validate()
+}
+
+// buildServer creates an MCP server with the Interzoid tools the filter
+// selects, and the resources, prompts and completions that describe them,
+// and returns it with its catalog. It is shared by the command and
+// NewServer, and every server it builds has a catalog of its own; o, when
+// given, is bound into each tool handler.
+func buildServer(tools toolFilter, o *embedOptions) (*server.MCPServer, *toolCatalog) {
+	s := server.NewMCPServer(
+		serverName,
+		serverVersion,
+		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(false, false),
+		server.WithPromptCapabilities(true),
+		server.WithCompletions(),
+		server.WithPromptCompletionProvider(completionProvider{}),
+		server.WithResourceCompletionProvider(completionProvider{}),
+		server.WithToolHandlerMiddleware(withConnectionHeaders),
+	)
+
+	// Register all Interzoid API tools, then the resources and prompts that
+	// describe them
+	catalog := registerAllTools(s)
+	filterTools(s, catalog, tools.allows)
+	if o != nil {
+		o.wrapTools(s)
+	}
+	registerResources(s, catalog)
+	registerReferenceTemplates(s)
+	registerPrompts(s)
+	return s, catalog
+}
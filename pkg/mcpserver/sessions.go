@@ -0,0 +1,569 @@
+package mcpserver
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============================================================================
+// SESSION STORE
+// ============================================================================
+//
+// Streamable HTTP sessions are identified by the Mcp-Session-Id header. By
+// default mcp-go only recognizes IDs issued by the same process, so a client
+// routed to another replica is rejected. Keeping session IDs in a store shared
+// by all replicas (a SQLite file on a shared volume) lets any replica accept
+// them. With -stateless no session IDs are issued at all.
+//
+// The store also tracks what each session has spent on upstream calls, so a
+// per-session budget (-session-budget) holds across replicas too, and buffers
+// the events of resumable streams (see streams.go), so a client can resume a
+// stream on any replica.
+// ============================================================================
+
+// sessions holds sessions, their spend and stream events. It is set from the
+// -session-store flag.
+var sessions sessionStore = newMemorySessionStore()
+
+// callSessions returns the session store of the server handling a call: its
+// own for a server built by NewServer, otherwise the command's.
+func callSessions(ctx context.Context) sessionStore {
+	if state, ok := ctx.Value(serverStateKey).(*serverState); ok {
+		return state.sessions
+	}
+	return sessions
+}
+
+// httpSessions issues and validates Streamable HTTP session IDs. It is set
+// from the -stateless and -session-store flags.
+var httpSessions server.SessionIdManager = storeSessionIdManager{sessions}
+
+// sessionTTL is how long an idle session is remembered.
+const sessionTTL = 24 * time.Hour
+
+var (
+	errUnknownSession = errors.New("unknown session")
+	errUnknownStream  = errors.New("unknown or expired event stream")
+)
+
+// sessionStore records Streamable HTTP sessions, what sessions have spent,
+// and the events of their resumable streams.
+type sessionStore interface {
+	// create records a new session.
+	create(id string) error
+	// touch marks a session as used and reports whether it was terminated.
+	// It returns errUnknownSession for IDs that were never issued or have
+	// expired.
+	touch(id string) (terminated bool, err error)
+	// terminate marks a session as ended by the client.
+	terminate(id string) error
+	// spend adds amount (atomic USDC) to what a session has spent, unless
+	// that would take it over limit; a limit of 0 means no limit. A negative
+	// amount returns money to the session. It reports whether the amount was
+	// added and the session's spend afterwards. Sessions of every transport
+	// can spend, not only the Streamable HTTP sessions the store issued.
+	spend(id string, amount, limit int64) (spent int64, ok bool, err error)
+
+	// openStream starts buffering a stream belonging to session, which may
+	// be empty in stateless mode.
+	openStream(stream, session string) error
+	// appendEvent buffers an event and returns its sequence number, starting
+	// at 1.
+	appendEvent(stream string, data []byte) (seq int64, err error)
+	// finishStream marks a stream as complete.
+	finishStream(stream string) error
+	// eventsAfter returns the buffered events after seq, the stream's
+	// session, and whether the stream is complete. It returns
+	// errUnknownStream for streams that were never opened or have expired.
+	eventsAfter(stream string, seq int64) (events []streamEvent, session string, done bool, err error)
+
+	close() error
+}
+
+// streamEvent is one buffered event of a resumable stream.
+type streamEvent struct {
+	seq  int64
+	data []byte
+}
+
+// newSessionID returns a random session ID.
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// storeSessionIdManager issues and validates session IDs through a
+// sessionStore. It implements server.SessionIdManager.
+type storeSessionIdManager struct {
+	store sessionStore
+}
+
+func (m storeSessionIdManager) Generate() string {
+	id := newSessionID()
+	if err := m.store.create(id); err != nil {
+		// The client will be rejected on its next request and re-initialize
+		log.Printf("Failed to record session: %v\n", err)
+	}
+	return id
+}
+
+func (m storeSessionIdManager) Validate(sessionID string) (isTerminated bool, err error) {
+	if sessionID == "" {
+		return false, errUnknownSession
+	}
+	return m.store.touch(sessionID)
+}
+
+func (m storeSessionIdManager) Terminate(sessionID string) (isNotAllowed bool, err error) {
+	return false, m.store.terminate(sessionID)
+}
+
+// ---------------------------------------------------------------------------
+// In-memory store
+// ---------------------------------------------------------------------------
+
+type memorySession struct {
+	lastSeen   time.Time
+	terminated bool
+}
+
+type memorySpend struct {
+	spent    int64
+	lastSeen time.Time
+}
+
+type memoryStream struct {
+	session string
+	events  []streamEvent
+	nextSeq int64
+	done    bool
+	updated time.Time
+}
+
+// memorySessionStore keeps sessions and streams in process memory. It is the
+// default and only suits a single replica.
+type memorySessionStore struct {
+	mu        sync.Mutex
+	sessions  map[string]*memorySession
+	spends    map[string]*memorySpend
+	streams   map[string]*memoryStream
+	lastSweep time.Time
+}
+
+func newMemorySessionStore() *memorySessionStore {
+	return &memorySessionStore{
+		sessions: make(map[string]*memorySession),
+		spends:   make(map[string]*memorySpend),
+		streams:  make(map[string]*memoryStream),
+	}
+}
+
+func (m *memorySessionStore) create(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+	m.sessions[id] = &memorySession{lastSeen: time.Now()}
+	return nil
+}
+
+func (m *memorySessionStore) touch(id string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok || time.Since(s.lastSeen) > sessionTTL {
+		return false, errUnknownSession
+	}
+	s.lastSeen = time.Now()
+	return s.terminated, nil
+}
+
+func (m *memorySessionStore) terminate(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return errUnknownSession
+	}
+	s.terminated = true
+	return nil
+}
+
+func (m *memorySessionStore) spend(id string, amount, limit int64) (int64, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+	sp, ok := m.spends[id]
+	if !ok || time.Since(sp.lastSeen) > sessionTTL {
+		sp = &memorySpend{}
+		m.spends[id] = sp
+	}
+	sp.lastSeen = time.Now()
+	if amount > 0 && limit > 0 && sp.spent+amount > limit {
+		return sp.spent, false, nil
+	}
+	sp.spent += amount
+	return sp.spent, true, nil
+}
+
+func (m *memorySessionStore) openStream(stream, session string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep()
+	m.streams[stream] = &memoryStream{session: session, nextSeq: 1, updated: time.Now()}
+	return nil
+}
+
+func (m *memorySessionStore) appendEvent(stream string, data []byte) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.streams[stream]
+	if !ok {
+		return 0, errUnknownStream
+	}
+	seq := st.nextSeq
+	st.nextSeq++
+	st.events = append(st.events, streamEvent{seq: seq, data: append([]byte(nil), data...)})
+	if len(st.events) > maxStreamEvents {
+		st.events = st.events[len(st.events)-maxStreamEvents:]
+	}
+	st.updated = time.Now()
+	return seq, nil
+}
+
+func (m *memorySessionStore) finishStream(stream string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.streams[stream]
+	if !ok {
+		return errUnknownStream
+	}
+	st.done = true
+	st.updated = time.Now()
+	return nil
+}
+
+func (m *memorySessionStore) eventsAfter(stream string, seq int64) ([]streamEvent, string, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.streams[stream]
+	if !ok || (st.done && time.Since(st.updated) > streamRetention) {
+		return nil, "", false, errUnknownStream
+	}
+	var events []streamEvent
+	for _, e := range st.events {
+		if e.seq > seq {
+			events = append(events, e)
+		}
+	}
+	return events, st.session, st.done, nil
+}
+
+// sweep drops expired sessions and streams, at most once per minute.
+// Callers must hold m.mu.
+func (m *memorySessionStore) sweep() {
+	if time.Since(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = time.Now()
+	for id, s := range m.sessions {
+		if time.Since(s.lastSeen) > sessionTTL {
+			delete(m.sessions, id)
+		}
+	}
+	for id, sp := range m.spends {
+		if time.Since(sp.lastSeen) > sessionTTL {
+			delete(m.spends, id)
+		}
+	}
+	for id, st := range m.streams {
+		// Streams left unfinished by a failed call expire with sessions
+		if (st.done && time.Since(st.updated) > streamRetention) || time.Since(st.updated) > sessionTTL {
+			delete(m.streams, id)
+		}
+	}
+}
+
+func (m *memorySessionStore) close() error { return nil }
+
+// ---------------------------------------------------------------------------
+// SQLite store
+// ---------------------------------------------------------------------------
+
+const sessionSchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	id         TEXT    PRIMARY KEY,
+	created    INTEGER NOT NULL,
+	last_seen  INTEGER NOT NULL,
+	terminated INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS sessions_last_seen ON sessions (last_seen);
+CREATE TABLE IF NOT EXISTS session_spend (
+	id        TEXT    PRIMARY KEY,
+	spent     INTEGER NOT NULL,
+	last_seen INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS streams (
+	id       TEXT    PRIMARY KEY,
+	session  TEXT    NOT NULL,
+	next_seq INTEGER NOT NULL DEFAULT 1,
+	done     INTEGER NOT NULL DEFAULT 0,
+	updated  INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS stream_events (
+	stream TEXT    NOT NULL,
+	seq    INTEGER NOT NULL,
+	data   BLOB    NOT NULL,
+	PRIMARY KEY (stream, seq)
+);
+`
+
+// sqliteSessionStore keeps sessions and streams in a SQLite file that several
+// replicas (or test processes) can share.
+type sqliteSessionStore struct {
+	db *sql.DB
+}
+
+// openSQLiteSessionStore opens (creating if necessary) the session store at
+// path.
+func openSQLiteSessionStore(path string) (*sqliteSessionStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create session store directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session store: %w", err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sessionSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize session store: %w", err)
+	}
+
+	return &sqliteSessionStore{db: db}, nil
+}
+
+func (st *sqliteSessionStore) create(id string) error {
+	now := time.Now().Unix()
+	expired := now - int64(sessionTTL.Seconds())
+	if _, err := st.db.Exec(`DELETE FROM sessions WHERE last_seen < ?`, expired); err != nil {
+		return err
+	}
+	if _, err := st.db.Exec(`DELETE FROM session_spend WHERE last_seen < ?`, expired); err != nil {
+		return err
+	}
+	_, err := st.db.Exec(`INSERT INTO sessions (id, created, last_seen) VALUES (?, ?, ?)`, id, now, now)
+	return err
+}
+
+func (st *sqliteSessionStore) touch(id string) (bool, error) {
+	now := time.Now().Unix()
+	res, err := st.db.Exec(`UPDATE sessions SET last_seen = ? WHERE id = ? AND last_seen >= ?`,
+		now, id, now-int64(sessionTTL.Seconds()))
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, errUnknownSession
+	}
+
+	var terminated bool
+	err = st.db.QueryRow(`SELECT terminated FROM sessions WHERE id = ?`, id).Scan(&terminated)
+	return terminated, err
+}
+
+func (st *sqliteSessionStore) terminate(id string) error {
+	res, err := st.db.Exec(`UPDATE sessions SET terminated = 1 WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errUnknownSession
+	}
+	return nil
+}
+
+func (st *sqliteSessionStore) spend(id string, amount, limit int64) (int64, bool, error) {
+	now := time.Now().Unix()
+	expired := now - int64(sessionTTL.Seconds())
+	if amount <= 0 || limit <= 0 {
+		limit = -1
+	}
+
+	// One statement, so concurrent replicas cannot both spend the last of
+	// a budget. Spend older than sessionTTL starts over from zero.
+	var spent int64
+	err := st.db.QueryRow(`
+		INSERT INTO session_spend (id, spent, last_seen)
+		SELECT ?1, ?2, ?3 WHERE ?4 < 0 OR ?2 <= ?4
+		ON CONFLICT (id) DO UPDATE SET
+			spent = CASE WHEN last_seen < ?5 THEN 0 ELSE spent END + excluded.spent,
+			last_seen = excluded.last_seen
+		WHERE ?4 < 0 OR CASE WHEN last_seen < ?5 THEN 0 ELSE spent END + excluded.spent <= ?4
+		RETURNING spent`, id, amount, now, limit, expired).Scan(&spent)
+	if err == nil {
+		return spent, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return 0, false, err
+	}
+
+	err = st.db.QueryRow(`SELECT CASE WHEN last_seen < ? THEN 0 ELSE spent END FROM session_spend WHERE id = ?`, expired, id).Scan(&spent)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	return spent, false, err
+}
+
+func (st *sqliteSessionStore) openStream(stream, session string) error {
+	now := time.Now().Unix()
+	// Streams left unfinished by a failed call expire with sessions
+	expired := `SELECT id FROM streams WHERE (done = 1 AND updated < ?) OR updated < ?`
+	cutoff, staleCutoff := now-int64(streamRetention.Seconds()), now-int64(sessionTTL.Seconds())
+	if _, err := st.db.Exec(`DELETE FROM stream_events WHERE stream IN (`+expired+`)`, cutoff, staleCutoff); err != nil {
+		return err
+	}
+	if _, err := st.db.Exec(`DELETE FROM streams WHERE id IN (`+expired+`)`, cutoff, staleCutoff); err != nil {
+		return err
+	}
+	_, err := st.db.Exec(`INSERT INTO streams (id, session, updated) VALUES (?, ?, ?)`, stream, session, now)
+	return err
+}
+
+func (st *sqliteSessionStore) appendEvent(stream string, data []byte) (int64, error) {
+	tx, err := st.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var seq int64
+	if err := tx.QueryRow(`SELECT next_seq FROM streams WHERE id = ?`, stream).Scan(&seq); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, errUnknownStream
+		}
+		return 0, err
+	}
+	if _, err := tx.Exec(`UPDATE streams SET next_seq = ?, updated = ? WHERE id = ?`, seq+1, time.Now().Unix(), stream); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`INSERT INTO stream_events (stream, seq, data) VALUES (?, ?, ?)`, stream, seq, data); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(`DELETE FROM stream_events WHERE stream = ? AND seq <= ?`, stream, seq-maxStreamEvents); err != nil {
+		return 0, err
+	}
+	return seq, tx.Commit()
+}
+
+func (st *sqliteSessionStore) finishStream(stream string) error {
+	_, err := st.db.Exec(`UPDATE streams SET done = 1, updated = ? WHERE id = ?`, time.Now().Unix(), stream)
+	return err
+}
+
+func (st *sqliteSessionStore) eventsAfter(stream string, seq int64) ([]streamEvent, string, bool, error) {
+	var session string
+	var done bool
+	var updated int64
+	err := st.db.QueryRow(`SELECT session, done, updated FROM streams WHERE id = ?`, stream).Scan(&session, &done, &updated)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && done && time.Since(time.Unix(updated, 0)) > streamRetention) {
+		return nil, "", false, errUnknownStream
+	}
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	rows, err := st.db.Query(`SELECT seq, data FROM stream_events WHERE stream = ? AND seq > ? ORDER BY seq`, stream, seq)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer rows.Close()
+
+	var events []streamEvent
+	for rows.Next() {
+		var e streamEvent
+		if err := rows.Scan(&e.seq, &e.data); err != nil {
+			return nil, "", false, err
+		}
+		events = append(events, e)
+	}
+	return events, session, done, rows.Err()
+}
+
+func (st *sqliteSessionStore) close() error {
+	return st.db.Close()
+}
+
+// ---------------------------------------------------------------------------
+// Session budgets
+// ---------------------------------------------------------------------------
+
+// sessionBudget is the most a session may spend on upstream calls, in atomic
+// USDC at x402 prices (0 means no budget). It is set from -session-budget or
+// budgets.session, which a configuration reload may change.
+var sessionBudget atomic.Int64
+
+// chargeSession takes cost (atomic USDC) from the budget of the calling
+// session before any upstream call is made. The returned refund gives back
+// the cost of calls that failed and were not billed. Calls without a
+// session, and all calls while no budget is set, are not charged.
+func chargeSession(ctx context.Context, cost int64) (refund func(amount int64), err error) {
+	noRefund := func(int64) {}
+	session := server.ClientSessionFromContext(ctx)
+	budget := sessionBudget.Load()
+	if budget <= 0 || cost <= 0 || session == nil || session.SessionID() == "" {
+		return noRefund, nil
+	}
+	id := session.SessionID()
+	store := callSessions(ctx)
+
+	spent, ok, err := store.spend(id, cost, budget)
+	if err != nil {
+		return nil, fmt.Errorf("could not check the session budget: %w", err)
+	}
+	if !ok {
+		return nil, newToolError(codeBudgetExceeded, "session budget exceeded: this call costs $%.4f, but only $%.4f of the $%.4f budget for this session remains",
+			float64(cost)/1e6, float64(budget-spent)/1e6, float64(budget)/1e6)
+	}
+
+	return func(amount int64) {
+		if amount <= 0 {
+			return
+		}
+		if _, _, err := store.spend(id, -amount, 0); err != nil {
+			log.Printf("Failed to refund session budget: %v\n", err)
+		}
+	}, nil
+}
+
+// runSession is the client session of calls made outside an MCP connection,
+// such as the rows of a pipe run or a REST caller's requests, so that the
+// session budget covers them as a whole.
+type runSession struct {
+	id string
+}
+
+func (r runSession) SessionID() string                                   { return r.id }
+func (r runSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return nil }
+func (r runSession) Initialize()                                         {}
+func (r runSession) Initialized() bool                                   { return true }
+
+// withRunSession returns a context for calls to s made in a new run session.
+func withRunSession(ctx context.Context, s *server.MCPServer) context.Context {
+	return s.WithContext(ctx, runSession{id: newSessionID()})
+}
@@ -0,0 +1,285 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// testStores returns an in-memory store and a SQLite store, each with a
+// function that backdates a session's last use by d.
+func testStores(t *testing.T) map[string]struct {
+	store    sessionStore
+	backdate func(id string, d time.Duration)
+} {
+	mem := newMemorySessionStore()
+	db, err := openSQLiteSessionStore(filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.close() })
+
+	return map[string]struct {
+		store    sessionStore
+		backdate func(id string, d time.Duration)
+	}{
+		"memory": {mem, func(id string, d time.Duration) {
+			mem.mu.Lock()
+			defer mem.mu.Unlock()
+			if s, ok := mem.sessions[id]; ok {
+				s.lastSeen = s.lastSeen.Add(-d)
+			}
+			if sp, ok := mem.spends[id]; ok {
+				sp.lastSeen = sp.lastSeen.Add(-d)
+			}
+		}},
+		"sqlite": {db, func(id string, d time.Duration) {
+			for _, table := range []string{"sessions", "session_spend"} {
+				if _, err := db.db.Exec(`UPDATE `+table+` SET last_seen = last_seen - ? WHERE id = ?`, int64(d.Seconds()), id); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}},
+	}
+}
+
+func TestSessionStoreLifecycle(t *testing.T) {
+	for name, tt := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			st := tt.store
+			if _, err := st.touch("never-issued"); !errors.Is(err, errUnknownSession) {
+				t.Errorf("touch of an unknown session = %v, want errUnknownSession", err)
+			}
+
+			if err := st.create("a"); err != nil {
+				t.Fatal(err)
+			}
+			if terminated, err := st.touch("a"); err != nil || terminated {
+				t.Errorf("touch = %v, %v, want a live session", terminated, err)
+			}
+			if err := st.terminate("a"); err != nil {
+				t.Fatal(err)
+			}
+			if terminated, err := st.touch("a"); err != nil || !terminated {
+				t.Errorf("touch after terminate = %v, %v, want terminated", terminated, err)
+			}
+			if err := st.terminate("never-issued"); !errors.Is(err, errUnknownSession) {
+				t.Errorf("terminate of an unknown session = %v", err)
+			}
+		})
+	}
+}
+
+func TestSessionStoreExpiry(t *testing.T) {
+	for name, tt := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			st := tt.store
+			st.create("idle")
+			st.create("busy")
+
+			// Use keeps a session alive past the TTL counted from its creation
+			tt.backdate("busy", sessionTTL-time.Hour)
+			if _, err := st.touch("busy"); err != nil {
+				t.Fatalf("touch of a recently used session = %v", err)
+			}
+
+			tt.backdate("idle", sessionTTL+time.Minute)
+			if _, err := st.touch("idle"); !errors.Is(err, errUnknownSession) {
+				t.Errorf("touch of an expired session = %v, want errUnknownSession", err)
+			}
+			if _, err := st.touch("busy"); err != nil {
+				t.Errorf("touch of a live session = %v", err)
+			}
+		})
+	}
+}
+
+func TestSessionStoreSpend(t *testing.T) {
+	for name, tt := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			st := tt.store
+			steps := []struct {
+				amount, limit int64
+				wantSpent     int64
+				wantOK        bool
+			}{
+				{60, 100, 60, true},
+				{30, 100, 90, true},
+				{20, 100, 90, false}, // over the limit, nothing added
+				{-30, 0, 60, true},   // refund
+				{40, 100, 100, true}, // exactly the limit
+				{500, 0, 600, true},  // no limit
+			}
+			for i, step := range steps {
+				spent, ok, err := st.spend("s", step.amount, step.limit)
+				if err != nil || spent != step.wantSpent || ok != step.wantOK {
+					t.Errorf("step %d: spend(%d, %d) = %d, %v, %v, want %d, %v", i, step.amount, step.limit, spent, ok, err, step.wantSpent, step.wantOK)
+				}
+			}
+
+			// A new session starts from zero, including one over its limit
+			if spent, ok, _ := st.spend("other", 200, 100); ok || spent != 0 {
+				t.Errorf("first spend over the limit = %d, %v, want refused at 0", spent, ok)
+			}
+
+			// Spend is forgotten with the session
+			tt.backdate("s", sessionTTL+time.Minute)
+			if spent, ok, _ := st.spend("s", 10, 100); !ok || spent != 10 {
+				t.Errorf("spend after expiry = %d, %v, want a fresh budget", spent, ok)
+			}
+		})
+	}
+}
+
+// Replicas sharing a SQLite store accept each other's sessions and share
+// their spend.
+func TestSQLiteSessionStoreSharedByReplicas(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.db")
+	replicas := make([]*server.StreamableHTTPServer, 2)
+	stores := make([]*sqliteSessionStore, 2)
+	for i := range replicas {
+		st, err := openSQLiteSessionStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer st.close()
+		stores[i] = st
+		replicas[i] = server.NewStreamableHTTPServer(server.NewMCPServer(serverName, serverVersion),
+			server.WithSessionIdManager(storeSessionIdManager{st}))
+	}
+
+	post := func(replica int, sessionID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", mcpPath, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if sessionID != "" {
+			req.Header.Set("Mcp-Session-Id", sessionID)
+		}
+		w := httptest.NewRecorder()
+		replicas[replica].ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(0, "", initializeRequest)
+	id := w.Header().Get("Mcp-Session-Id")
+	if id == "" {
+		t.Fatalf("no session ID issued: %d %s", w.Code, w.Body)
+	}
+
+	if w = post(1, id, `{"jsonrpc":"2.0","id":2,"method":"ping"}`); w.Code != http.StatusOK {
+		t.Errorf("ping on the other replica = %d %s", w.Code, w.Body)
+	}
+
+	if w = post(1, "forged", `{"jsonrpc":"2.0","id":3,"method":"ping"}`); w.Code == http.StatusOK {
+		t.Error("a session ID that was never issued was accepted")
+	}
+
+	stores[0].spend(id, 70, 100)
+	if _, ok, _ := stores[1].spend(id, 40, 100); ok {
+		t.Error("the other replica let the session exceed its budget")
+	}
+}
+
+// withSessionBudget sets a session budget and a fresh session store for
+// the rest of the test.
+func withSessionBudget(t *testing.T, budget int64) {
+	previousBudget, previousStore := sessionBudget.Load(), sessions
+	sessionBudget.Store(budget)
+	sessions = newMemorySessionStore()
+	t.Cleanup(func() {
+		sessionBudget.Store(previousBudget)
+		sessions = previousStore
+	})
+}
+
+// sessionContext returns a context for calls made in the session id.
+func sessionContext(id string) context.Context {
+	s := server.NewMCPServer(serverName, serverVersion)
+	return s.WithContext(context.Background(), &wsSession{id: id, notifications: make(chan mcp.JSONRPCNotification, 1)})
+}
+
+func TestSessionBudgetToolCall(t *testing.T) {
+	withSessionBudget(t, 2*premiumTier.atomicUSDC)
+	fail := false
+	calls := stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"Code":"Success"}`))
+	})
+	header := http.Header{"Authorization": {"Bearer key"}}
+	call := func(ctx context.Context, lookups ...interface{}) string {
+		t.Helper()
+		var lookup interface{} = lookups
+		if len(lookups) == 1 {
+			lookup = lookups[0]
+		}
+		result, err := callToolHandler(ctx, t, "interzoid_business_info", map[string]interface{}{"lookup": lookup}, header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.IsError {
+			return resultText(result)
+		}
+		return ""
+	}
+	ctx := sessionContext("session-1")
+
+	// A batch larger than the budget is refused whole
+	if msg := call(ctx, "a.com", "b.com", "c.com"); !strings.Contains(msg, "session budget exceeded") {
+		t.Errorf("batch over budget = %q, want a budget error", msg)
+	}
+	if calls() != 0 {
+		t.Fatal("the refused batch reached the API")
+	}
+
+	// A failed call is given back
+	fail = true
+	call(ctx, "a.com")
+	fail = false
+	if msg := call(ctx, "a.com", "b.com"); msg != "" {
+		t.Fatalf("calls within budget failed: %s", msg)
+	}
+	if msg := call(ctx, "c.com"); !strings.Contains(msg, "only $0.0000 of the $0.6250 budget") {
+		t.Errorf("call over budget = %q", msg)
+	}
+
+	// Other sessions have their own budget
+	if msg := call(sessionContext("session-2"), "c.com"); msg != "" {
+		t.Errorf("call in another session failed: %s", msg)
+	}
+	if msg := call(context.Background(), "c.com"); msg != "" {
+		t.Errorf("call without a session failed: %s", msg)
+	}
+}
+
+func TestSessionBudgetPaymentRequired(t *testing.T) {
+	withSessionBudget(t, premiumTier.atomicUSDC)
+	paid := false
+	stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		if !paid {
+			w.WriteHeader(http.StatusPaymentRequired)
+			w.Write([]byte(`{"x402Version":1,"accepts":[{"scheme":"exact","network":"base"}]}`))
+			return
+		}
+		w.Write([]byte(`{"Code":"Success"}`))
+	})
+	t.Setenv("INTERZOID_API_KEY", "")
+	ctx := sessionContext("session-1")
+
+	// Payment requirements are not charged, so the paid call still fits
+	for _, paid = range []bool{false, true} {
+		result, err := callToolHandler(ctx, t, "interzoid_business_info", map[string]interface{}{"lookup": "a.com"}, nil)
+		if err != nil || result.IsError {
+			t.Fatalf("call (paid %v) = %v, %s", paid, err, resultText(result))
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============================================================================
+// RESUMABLE STREAMS
+// ============================================================================
+//
+// mcp-go writes Streamable HTTP events without IDs and drops the response of
+// a tool call whose client has disconnected. resumableStreams sits in front
+// of it and, for tool calls from clients that accept event streams:
+//
+//   - answers with an event stream straight away, starting with an empty
+//     priming event, so the client has an event ID before the call finishes;
+//   - lets the call run to completion even if the client disconnects;
+//   - gives every event an ID and buffers it in the session store;
+//   - replays the events after Last-Event-ID when the client reconnects with
+//     a GET, then follows the stream until the call finishes.
+//
+// Event IDs have the form <stream>:<seq>. Buffered events are kept for
+// streamRetention after the stream finishes, up to maxStreamEvents per stream.
+// ============================================================================
+
+// streamRetention is how long the events of a finished stream are kept for
+// clients to resume. It is set from the -stream-retention flag.
+var streamRetention = 10 * time.Minute
+
+const (
+	// maxStreamEvents bounds the events buffered per stream; older ones are
+	// dropped first
+	maxStreamEvents = 1000
+
+	// streamPollInterval is how often a resumed stream checks for new events
+	// while the call is still running, possibly on another replica
+	streamPollInterval = 250 * time.Millisecond
+)
+
+// resumableStreams wraps the Streamable HTTP handler.
+func resumableStreams(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.Header.Get("Last-Event-ID") != "":
+			stream, seq, ok := parseEventID(r.Header.Get("Last-Event-ID"))
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			resumeStream(w, r, stream, seq)
+
+		case r.Method == http.MethodPost && acceptsEventStream(r):
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			sessionID := r.Header.Get(server.HeaderKeySessionID)
+			requestID, ok := toolCallID(body)
+			if !ok || !validSession(sessionID) {
+				// Leave anything else, including the error for a bad
+				// session, to mcp-go
+				next.ServeHTTP(w, r)
+				return
+			}
+			serveResumable(w, r, next, sessionID, requestID)
+
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// acceptsEventStream reports whether a POST carries JSON and its client
+// accepts an event stream in response.
+func acceptsEventStream(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json" &&
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream")
+}
+
+// toolCallID returns the request ID of a JSON-RPC tools/call request, and
+// false for any other message.
+func toolCallID(body []byte) (json.RawMessage, bool) {
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	if json.Unmarshal(body, &msg) != nil || msg.Method != string(mcp.MethodToolsCall) {
+		return nil, false
+	}
+	return msg.ID, true
+}
+
+// validSession reports whether mcp-go will accept the session ID.
+func validSession(sessionID string) bool {
+	terminated, err := httpSessions.Validate(sessionID)
+	return err == nil && !terminated
+}
+
+func formatEventID(stream string, seq int64) string {
+	return fmt.Sprintf("%s:%d", stream, seq)
+}
+
+func parseEventID(id string) (stream string, seq int64, ok bool) {
+	stream, s, found := strings.Cut(id, ":")
+	if !found || stream == "" {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseInt(s, 10, 64)
+	return stream, seq, err == nil && seq >= 0
+}
+
+// startEventStream writes the headers of an event stream response.
+func startEventStream(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeEvent writes one event to the client. Errors are ignored: the client
+// may have gone away, and the event is buffered for it to resume.
+func writeEvent(w http.ResponseWriter, id string, data []byte) {
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// serveResumable runs a tool call as a resumable stream.
+func serveResumable(w http.ResponseWriter, r *http.Request, next http.Handler, sessionID string, requestID json.RawMessage) {
+	stream := newSessionID()
+	if err := sessions.openStream(stream, sessionID); err != nil {
+		log.Printf("Resumable stream disabled for this call: %v\n", err)
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	startEventStream(w)
+	// Priming event: an ID to resume from, with no data
+	fmt.Fprintf(w, "id: %s\ndata: \n\n", formatEventID(stream, 0))
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+
+	rec := &streamRecorder{w: w, stream: stream, requestID: requestID, header: make(http.Header)}
+	next.ServeHTTP(rec, r.WithContext(context.WithoutCancel(r.Context())))
+	rec.finish()
+}
+
+// streamRecorder receives mcp-go's response to a tool call and re-emits it
+// as buffered, numbered events. mcp-go answers either with an event stream
+// (when the call sends notifications) or with a single JSON body. The
+// stream has already been answered with 200 OK, so an error status from
+// mcp-go is re-emitted as a JSON-RPC error event for the call.
+type streamRecorder struct {
+	w         http.ResponseWriter
+	stream    string
+	requestID json.RawMessage
+	header    http.Header
+
+	mu        sync.Mutex
+	eventMode bool
+	status    int
+	buf       bytes.Buffer
+}
+
+// Header returns a header map that is discarded: the response headers were
+// sent when the stream started.
+func (rec *streamRecorder) Header() http.Header { return rec.header }
+
+func (rec *streamRecorder) WriteHeader(status int) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.status != 0 {
+		return
+	}
+	rec.status = status
+	rec.eventMode = status < http.StatusMultipleChoices &&
+		strings.HasPrefix(rec.header.Get("Content-Type"), "text/event-stream")
+}
+
+func (rec *streamRecorder) Write(p []byte) (int, error) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.buf.Write(p)
+	if rec.eventMode {
+		rec.emitEvents()
+	}
+	return len(p), nil
+}
+
+// Flush satisfies http.Flusher; events are flushed as they are emitted.
+func (rec *streamRecorder) Flush() {}
+
+// emitEvents emits each complete event in the buffer. Callers must hold
+// rec.mu.
+func (rec *streamRecorder) emitEvents() {
+	for {
+		frame, rest, found := bytes.Cut(rec.buf.Bytes(), []byte("\n\n"))
+		if !found {
+			return
+		}
+		var data [][]byte
+		for _, line := range bytes.Split(frame, []byte("\n")) {
+			if d, ok := bytes.CutPrefix(line, []byte("data: ")); ok {
+				data = append(data, d)
+			}
+		}
+		rec.emit(bytes.Join(data, []byte("\n")))
+
+		remaining := append([]byte(nil), rest...)
+		rec.buf.Reset()
+		rec.buf.Write(remaining)
+	}
+}
+
+// emit buffers one event in the session store and sends it to the client.
+// Callers must hold rec.mu.
+func (rec *streamRecorder) emit(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	seq, err := sessions.appendEvent(rec.stream, data)
+	if err != nil {
+		// Still deliver the event, but without an ID it cannot be resumed
+		log.Printf("Failed to buffer stream event: %v\n", err)
+		writeEvent(rec.w, "", data)
+		return
+	}
+	writeEvent(rec.w, formatEventID(rec.stream, seq), data)
+}
+
+// finish emits a single JSON response, or the error mcp-go answered with,
+// as the final event and marks the stream complete.
+func (rec *streamRecorder) finish() {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	switch {
+	case rec.status >= http.StatusMultipleChoices:
+		rec.emit(rec.errorEvent())
+	case !rec.eventMode:
+		rec.emit(bytes.TrimSpace(rec.buf.Bytes()))
+	}
+	if err := sessions.finishStream(rec.stream); err != nil {
+		log.Printf("Failed to finish stream: %v\n", err)
+	}
+}
+
+// errorEvent converts an error response from mcp-go into a JSON-RPC error
+// for the call. A body that already is a JSON-RPC message is kept as is.
+// Callers must hold rec.mu.
+func (rec *streamRecorder) errorEvent() []byte {
+	body := bytes.TrimSpace(rec.buf.Bytes())
+	var msg struct {
+		JSONRPC string `json:"jsonrpc"`
+	}
+	if json.Unmarshal(body, &msg) == nil && msg.JSONRPC != "" {
+		return body
+	}
+
+	code := mcp.INVALID_REQUEST
+	if rec.status >= http.StatusInternalServerError {
+		code = mcp.INTERNAL_ERROR
+	}
+	message := http.StatusText(rec.status)
+	if len(body) > 0 {
+		message = string(body)
+	}
+	event, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": mcp.JSONRPC_VERSION,
+		"id":      rec.requestID,
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": fmt.Sprintf("HTTP %d: %s", rec.status, message),
+		},
+	})
+	return event
+}
+
+// resumeStream replays the events of a stream after seq, then follows the
+// stream until it finishes or the client goes away.
+func resumeStream(w http.ResponseWriter, r *http.Request, stream string, seq int64) {
+	events, session, done, err := sessions.eventsAfter(stream, seq)
+	if err != nil || session != r.Header.Get(server.HeaderKeySessionID) {
+		http.Error(w, errUnknownStream.Error(), http.StatusNotFound)
+		return
+	}
+
+	startEventStream(w)
+	for {
+		for _, e := range events {
+			writeEvent(w, formatEventID(stream, e.seq), e.data)
+			seq = e.seq
+		}
+		if done {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(streamPollInterval):
+		}
+
+		if events, _, done, err = sessions.eventsAfter(stream, seq); err != nil {
+			return
+		}
+	}
+}
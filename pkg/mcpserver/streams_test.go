@@ -0,0 +1,316 @@
+package mcpserver
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// withSessionStore uses st for sessions and streams for the rest of the test.
+func withSessionStore(t *testing.T, st sessionStore) {
+	previousStore, previousManager := sessions, httpSessions
+	sessions, httpSessions = st, storeSessionIdManager{st}
+	t.Cleanup(func() { sessions, httpSessions = previousStore, previousManager })
+}
+
+func TestStreamEvents(t *testing.T) {
+	for name, tt := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			st := tt.store
+			if _, _, _, err := st.eventsAfter("never-opened", 0); err != errUnknownStream {
+				t.Errorf("eventsAfter of an unknown stream = %v", err)
+			}
+			if _, err := st.appendEvent("never-opened", []byte("x")); err != errUnknownStream {
+				t.Errorf("appendEvent to an unknown stream = %v", err)
+			}
+
+			st.openStream("s", "session-1")
+			for i := 1; i <= 3; i++ {
+				if seq, err := st.appendEvent("s", []byte(fmt.Sprint("event ", i))); err != nil || seq != int64(i) {
+					t.Fatalf("appendEvent = %d, %v, want %d", seq, err, i)
+				}
+			}
+
+			events, session, done, err := st.eventsAfter("s", 1)
+			if err != nil || session != "session-1" || done || len(events) != 2 || string(events[0].data) != "event 2" || events[1].seq != 3 {
+				t.Errorf("eventsAfter(1) = %v, %q, %v, %v", events, session, done, err)
+			}
+
+			st.finishStream("s")
+			if events, _, done, _ := st.eventsAfter("s", 3); !done || len(events) != 0 {
+				t.Errorf("eventsAfter(3) of a finished stream = %v, done %v", events, done)
+			}
+		})
+	}
+}
+
+func TestStreamEventsBounded(t *testing.T) {
+	for name, tt := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			st := tt.store
+			st.openStream("s", "")
+			for i := 0; i < maxStreamEvents+5; i++ {
+				st.appendEvent("s", []byte("e"))
+			}
+			events, _, _, err := st.eventsAfter("s", 0)
+			if err != nil || len(events) != maxStreamEvents || events[0].seq != 6 {
+				t.Errorf("kept %d events from seq %d, %v; want the last %d", len(events), events[0].seq, err, maxStreamEvents)
+			}
+		})
+	}
+}
+
+func TestStreamRetention(t *testing.T) {
+	previous := streamRetention
+	streamRetention = 0
+	t.Cleanup(func() { streamRetention = previous })
+
+	for name, tt := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			st := tt.store
+			st.openStream("running", "")
+			st.openStream("finished", "")
+			st.finishStream("finished")
+			time.Sleep(1100 * time.Millisecond) // SQLite keeps whole seconds
+
+			if _, _, _, err := st.eventsAfter("running", 0); err != nil {
+				t.Errorf("a running stream expired: %v", err)
+			}
+			if _, _, _, err := st.eventsAfter("finished", 0); err != errUnknownStream {
+				t.Errorf("eventsAfter of an expired stream = %v, want errUnknownStream", err)
+			}
+		})
+	}
+}
+
+// sseEvent is one event read from an event stream.
+type sseEvent struct {
+	id, data string
+}
+
+// readEvents returns the events of an event stream as they arrive.
+func readEvents(body io.Reader) <-chan sseEvent {
+	ch := make(chan sseEvent)
+	go func() {
+		defer close(ch)
+		scanner := bufio.NewScanner(body)
+		var e sseEvent
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				ch <- e
+				e = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				e.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				e.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+	return ch
+}
+
+// nextEvent waits for the next event with data, or fails the test.
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				t.Fatal("stream ended")
+			}
+			if e.data != "" {
+				return e
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an event")
+		}
+	}
+}
+
+// streamableServer starts a Streamable HTTP server with every tool
+// registered and returns it with an initialized session ID.
+func streamableServer(t *testing.T) (*httptest.Server, string) {
+	t.Helper()
+	withSessionStore(t, newMemorySessionStore())
+	s := server.NewMCPServer(serverName, serverVersion, server.WithToolCapabilities(false))
+	registerAllTools(s)
+	ts := httptest.NewServer(newHTTPMux(s, "http", false, false, httpContextFunc))
+	t.Cleanup(ts.Close)
+
+	resp, err := http.Post(ts.URL+mcpPath, "application/json", strings.NewReader(initializeRequest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return ts, resp.Header.Get(server.HeaderKeySessionID)
+}
+
+// streamRequest makes a Streamable HTTP request in session that accepts an
+// event stream.
+func streamRequest(ctx context.Context, t *testing.T, method, url, session, lastEventID, body string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set(server.HeaderKeySessionID, session)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+const genderCall = `{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"interzoid_gender","arguments":{"name":"Maria"}}}`
+
+// A call whose client disconnects runs to completion, and the client gets
+// the result by resuming from the priming event.
+func TestResumeCallThatOutlivesItsClient(t *testing.T) {
+	release := make(chan struct{})
+	calls := stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte(`{"Gender":"F","Code":"Success"}`))
+	})
+	ts, session := streamableServer(t)
+
+	ctx, disconnect := context.WithCancel(context.Background())
+	resp := streamRequest(ctx, t, "POST", ts.URL+mcpPath, session, "", genderCall)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("tools/call = %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	priming := <-readEvents(resp.Body)
+	if _, seq, ok := parseEventID(priming.id); !ok || seq != 0 || priming.data != "" {
+		t.Fatalf("priming event = %+v", priming)
+	}
+	disconnect()
+	resp.Body.Close()
+	close(release)
+
+	resp = streamRequest(context.Background(), t, "GET", ts.URL+mcpPath, session, priming.id, "")
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("resume = %d", resp.StatusCode)
+	}
+	result := nextEvent(t, readEvents(resp.Body))
+	if !strings.Contains(result.data, `"id":7`) || !strings.Contains(result.data, `\"Gender\": \"F\"`) {
+		t.Errorf("resumed result = %s", result.data)
+	}
+	if calls() != 1 {
+		t.Errorf("upstream calls = %d, want 1", calls())
+	}
+}
+
+// Resuming after an event replays only the events that followed it.
+func TestReplayAfterLastEventID(t *testing.T) {
+	stubUpstream(t, answer(`{"Gender":"F","Code":"Success"}`))
+	ts, session := streamableServer(t)
+
+	resp := streamRequest(context.Background(), t, "POST", ts.URL+mcpPath, session, "", genderCall)
+	events := readEvents(resp.Body)
+	priming := <-events
+	result := nextEvent(t, events)
+	resp.Body.Close()
+
+	resp = streamRequest(context.Background(), t, "GET", ts.URL+mcpPath, session, priming.id, "")
+	if replayed := nextEvent(t, readEvents(resp.Body)); replayed != result {
+		t.Errorf("replay from the priming event = %+v, want %+v", replayed, result)
+	}
+	resp.Body.Close()
+
+	// Nothing follows the final event, and the finished stream closes
+	resp = streamRequest(context.Background(), t, "GET", ts.URL+mcpPath, session, result.id, "")
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || strings.Contains(string(body), "data: {") {
+		t.Errorf("replay after the last event = %d %q", resp.StatusCode, body)
+	}
+
+	// Only the session that made the call can resume it
+	resp = streamRequest(context.Background(), t, "GET", ts.URL+mcpPath, "another-session", priming.id, "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("resume from another session = %d, want 404", resp.StatusCode)
+	}
+}
+
+// An error status from the Streamable HTTP handler arrives as a JSON-RPC
+// error event, since the stream has already been answered with 200 OK.
+func TestResumableStreamErrorStatus(t *testing.T) {
+	withSessionStore(t, newMemorySessionStore())
+	session := httpSessions.Generate()
+
+	tests := []struct {
+		name string
+		next http.HandlerFunc
+		want string
+	}{
+		{
+			name: "plain text",
+			next: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Invalid session ID", http.StatusBadRequest)
+			},
+			want: `{"error":{"code":-32600,"message":"HTTP 400: Invalid session ID"},"id":7,"jsonrpc":"2.0"}`,
+		},
+		{
+			name: "empty server error",
+			next: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			want: `{"error":{"code":-32603,"message":"HTTP 500: Internal Server Error"},"id":7,"jsonrpc":"2.0"}`,
+		},
+		{
+			name: "JSON-RPC error body",
+			next: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"jsonrpc":"2.0","id":7,"error":{"code":-32602,"message":"bad params"}}`))
+			},
+			want: `{"jsonrpc":"2.0","id":7,"error":{"code":-32602,"message":"bad params"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", mcpPath, strings.NewReader(genderCall))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json, text/event-stream")
+			req.Header.Set(server.HeaderKeySessionID, session)
+			w := httptest.NewRecorder()
+			resumableStreams(tt.next).ServeHTTP(w, req)
+
+			events := readEvents(w.Body)
+			priming := <-events
+			got := nextEvent(t, events)
+			if got.data != tt.want {
+				t.Errorf("event = %s, want %s", got.data, tt.want)
+			}
+
+			// The error is buffered like any other event
+			stream, _, _ := parseEventID(priming.id)
+			buffered, _, done, _ := sessions.eventsAfter(stream, 0)
+			if !done || len(buffered) != 1 || string(buffered[0].data) != tt.want {
+				t.Errorf("buffered = %v, done %v", buffered, done)
+			}
+		})
+	}
+}
+
+func TestToolCallID(t *testing.T) {
+	if id, ok := toolCallID([]byte(genderCall)); !ok || string(id) != "7" {
+		t.Errorf("toolCallID = %s, %v", id, ok)
+	}
+	if _, ok := toolCallID([]byte(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)); ok {
+		t.Error("tools/list taken for a tool call")
+	}
+}
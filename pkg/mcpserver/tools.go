@@ -1,10 +1,11 @@
-package main
+package mcpserver
 
 import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
+	"strconv"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -26,24 +27,45 @@ import (
 //   Standard APIs:  $0.0125 per call  (12500 atomic units)
 //   Premium APIs:   $0.3125 per call  (312500 atomic units)
 //
-// Total: 29 APIs (gettechstack excluded for now)
+// Total: 31 APIs (gettechstack excluded for now)
 // ============================================================================
 
-// getAPIKey extracts the API key using the following priority:
+// defaultAPIKey is the key used when a request carries none: api.key in the
+// configuration, or INTERZOID_API_KEY. It is set only when the command loads
+// its configuration, so a server built by NewServer has none.
+var defaultAPIKey string
+
+// getAPIKey returns the API key for a tool call: from the key function of a
+// server built by NewServer with WithKeyFunc, or else from DefaultKey.
+func getAPIKey(ctx context.Context, request mcp.CallToolRequest) string {
+	if keyFunc, ok := ctx.Value(keyFuncKey).(KeyFunc); ok {
+		return keyFunc(ctx, request)
+	}
+	return DefaultKey(ctx, request)
+}
+
+// DefaultKey extracts the API key using the following priority:
 //   1. Authorization header from the incoming MCP request (remote HTTP transport)
-//   2. INTERZOID_API_KEY environment variable (local stdio transport)
+//   2. The command's api.key or INTERZOID_API_KEY (local stdio transport);
+//      never set in a program that embeds the server
 //   3. Empty string — triggers x402 payment flow
-func getAPIKey(request mcp.CallToolRequest) string {
-	// Check for Authorization: Bearer <key> header from the connecting client
-	if auth := request.Header.Get("Authorization"); auth != "" {
-		// Strip "Bearer " prefix if present
-		if len(auth) > 7 && (auth[:7] == "Bearer " || auth[:7] == "bearer ") {
-			return auth[7:]
-		}
-		return auth
+func DefaultKey(ctx context.Context, request mcp.CallToolRequest) string {
+	if key := headerAPIKey(request); key != "" {
+		return key
+	}
+	// Fall back to the configured key
+	return defaultAPIKey
+}
+
+// headerAPIKey returns the key from the connecting client's Authorization
+// header, if it sent one.
+func headerAPIKey(request mcp.CallToolRequest) string {
+	auth := request.Header.Get("Authorization")
+	// Strip "Bearer " prefix if present
+	if len(auth) > 7 && (auth[:7] == "Bearer " || auth[:7] == "bearer ") {
+		return auth[7:]
 	}
-	// Fall back to environment variable
-	return os.Getenv("INTERZOID_API_KEY")
+	return auth
 }
 
 // getArguments safely extracts the arguments map from the request,
@@ -61,163 +83,268 @@ func getArguments(request mcp.CallToolRequest) map[string]interface{} {
 	}
 }
 
+// coerceArgument converts a scalar JSON argument to its query-string form.
+// Agents often send numbers or booleans for values such as ZIP codes, so
+// these are accepted alongside strings.
+//
+// The schemas themselves stay string-typed: no Interzoid API takes a numeric
+// or boolean parameter, and values such as ZIP codes would lose leading
+// zeros if declared as numbers.
+func coerceArgument(raw interface{}) (string, bool) {
+	switch v := raw.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case json.Number:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
 // genericHandler creates a tool handler that calls the Interzoid API.
 // paramMap maps MCP tool parameter names to API query parameter names.
 // This allows the tool to use descriptive param names while sending the
 // correct query param names to the API.
+//
+// When a required parameter is passed as an array, the call is expanded
+// into one upstream call per element (see batch.go).
 func genericHandler(endpoint string, requiredParams []paramMapping, optionalParams []paramMapping) server.ToolHandlerFunc {
 	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		apiKey := getAPIKey(request)
+		apiKey := getAPIKey(ctx, request)
 		args := getArguments(request)
 
-		params := make(map[string]string)
-
-		// Required params
-		for _, p := range requiredParams {
-			raw, ok := args[p.toolName]
-			if !ok {
-				return mcp.NewToolResultError(fmt.Sprintf("Missing required parameter: %s", p.toolName)), nil
-			}
-			val, ok := raw.(string)
-			if !ok {
-				return mcp.NewToolResultError(fmt.Sprintf("Parameter %s must be a string", p.toolName)), nil
-			}
-			params[p.apiName] = val
+		calls, isBatch, errMsg := expandArguments(args, requiredParams, optionalParams)
+		if errMsg != "" {
+			return toolErrorResult(newToolError(codeInvalidInput, "%s", errMsg)), nil
 		}
 
-		// Optional params
-		for _, p := range optionalParams {
-			raw, ok := args[p.toolName]
-			if ok {
-				if s, ok := raw.(string); ok && s != "" {
-					params[p.apiName] = s
-				}
-			}
+		// Refuse calls that cannot be paid for with the remaining credits
+		if err := checkCredits(ctx, apiKey, int64(len(calls))*tierForEndpoint(endpoint).credits); err != nil {
+			return toolErrorResult(err), nil
 		}
 
-		result, err := callInterzoidAPI(apiKey, endpoint, params)
+		// Take the cost from the session's budget, giving back the cost of
+		// calls that fail or only return x402 payment requirements
+		price := tierForEndpoint(endpoint).atomicUSDC
+		refund, err := chargeSession(ctx, int64(len(calls))*price)
 		if err != nil {
-			return mcp.NewToolResultError(err.Error()), nil
+			return toolErrorResult(err), nil
 		}
 
-		jsonBytes, err := json.MarshalIndent(result, "", "  ")
+		if isBatch {
+			batch := runBatch(ctx, request, endpoint, apiKey, calls)
+			refund(int64(batch.Failed+batch.Cached) * price)
+			return formatResult(batch)
+		}
+
+		result, cached, err := callEndpoint(ctx, request, endpoint, apiKey, calls[0])
+		if err != nil || cached || paymentRequired(result) {
+			refund(price)
+		}
 		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Failed to format response: %v", err)), nil
+			return toolErrorResult(err), nil
 		}
 
-		return mcp.NewToolResultText(string(jsonBytes)), nil
+		return formatResult(result)
+	}
+}
+
+// callEndpoint makes a single upstream call on behalf of a tool, applying the
+// inbound rate limit and recording usage and credit balance. cached reports
+// that the result came from the response cache, at no charge.
+func callEndpoint(ctx context.Context, request mcp.CallToolRequest, endpoint, apiKey string, params map[string]string) (result map[string]interface{}, cached bool, err error) {
+	// Enforce inbound rate limits before spending anything upstream
+	if err := checkRateLimit(ctx, request); err != nil {
+		return nil, false, err
+	}
+
+	// Answer from the response cache without spending anything, even while
+	// the endpoint's circuit is open
+	start := time.Now()
+	if result, ok := apiClient(ctx, apiKey).Cached(endpoint, queryValues(params)); ok {
+		recordUsage(ctx, request, endpoint, apiKey, start, result, nil, true)
+		reportRequestedAlgorithm(result, params)
+		return result, true, nil
+	}
+
+	result, err = callInterzoidAPI(ctx, apiKey, endpoint, params)
+	recordUsage(ctx, request, endpoint, apiKey, start, result, err, false)
+	if err != nil {
+		return nil, false, err
+	}
+	callCredits(ctx).observe(apiKey, result)
+	reportRequestedAlgorithm(result, params)
+	return result, false, nil
+}
+
+// reportRequestedAlgorithm adds the algorithm variant the caller asked for
+// to a lookup result as RequestedAlgorithm. Which variant Interzoid used is
+// only known from its own Algorithm field, which is left as it is.
+func reportRequestedAlgorithm(result map[string]interface{}, params map[string]string) {
+	if algorithm, ok := params["algorithm"]; ok && !paymentRequired(result) {
+		result["RequestedAlgorithm"] = algorithm
+	}
+}
+
+// paymentRequired reports whether result is an x402 payment_required answer
+// rather than the outcome of the lookup.
+func paymentRequired(result map[string]interface{}) bool {
+	return result["status"] == "payment_required"
+}
+
+// formatResult renders a result as indented JSON tool output.
+func formatResult(result interface{}) (*mcp.CallToolResult, error) {
+	jsonBytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return toolErrorResult(fmt.Errorf("failed to format response: %w", err)), nil
 	}
+
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// addAPITool registers an Interzoid API as an MCP tool backed by
+// genericHandler and records it in catalog. Required parameters
+// also accept arrays for batch lookups.
+func addAPITool(s *server.MCPServer, catalog *toolCatalog, category string, tool mcp.Tool, endpoint string, requiredParams []paramMapping, optionalParams []paramMapping) {
+	tool = annotateTool(tool, category, tierForEndpoint(endpoint), true)
+	catalog.add(category, tool, endpoint, requiredParams, optionalParams)
+	allowBatchArguments(&tool, requiredParams)
+	s.AddTool(tool, genericHandler(endpoint, requiredParams, optionalParams))
 }
 
 // paramMapping maps a tool-facing parameter name to the actual API query parameter name.
 // When they're the same, use same() helper. When different, use mapped().
+// Optional rules validate and normalize the value before any upstream call
+// (see validate.go).
 type paramMapping struct {
-	toolName string // name shown to the LLM / MCP client
-	apiName  string // actual query parameter name sent to the API
+	toolName string      // name shown to the LLM / MCP client
+	apiName  string      // actual query parameter name sent to the API
+	rules    []paramRule // validation/normalization applied in order
 }
 
-func same(name string) paramMapping {
-	return paramMapping{toolName: name, apiName: name}
+func same(name string, rules ...paramRule) paramMapping {
+	return paramMapping{toolName: name, apiName: name, rules: rules}
 }
 
-func mapped(toolName, apiName string) paramMapping {
-	return paramMapping{toolName: toolName, apiName: apiName}
+func mapped(toolName, apiName string, rules ...paramRule) paramMapping {
+	return paramMapping{toolName: toolName, apiName: apiName, rules: rules}
 }
 
-// registerAllTools registers every Interzoid API as an MCP tool.
-func registerAllTools(s *server.MCPServer) {
+// withAlgorithm declares a tool's optional algorithm parameter as an enum of
+// the variants the tool accepts.
+func withAlgorithm(toolName string) mcp.ToolOption {
+	return mcp.WithString("algorithm",
+		mcp.Enum(algorithmVariants[toolName]...),
+		mcp.Description("Algorithm variant (optional; Interzoid picks its default when omitted)"),
+	)
+}
+
+// algorithmParam maps a tool's algorithm parameter, rejecting variants the
+// tool does not accept.
+func algorithmParam(toolName string) paramMapping {
+	return same("algorithm", oneOf(algorithmVariants[toolName]...))
+}
+
+// registerAllTools registers every Interzoid API as an MCP tool, and
+// returns a new catalog of the API tools.
+func registerAllTools(s *server.MCPServer) *toolCatalog {
+	catalog := newToolCatalog()
 
 	// =====================================================================
 	// DATA MATCHING — Similarity Key Generation & Scoring
 	// =====================================================================
 
 	// /getcompanymatchadvanced?company=[name]&algorithm=[algo]
-	s.AddTool(
+	addAPITool(s, catalog, catMatching,
 		mcp.NewTool("interzoid_company_match_advanced",
 			mcp.WithDescription("Generate an advanced AI-powered similarity key for company/organization name matching. Names like 'IBM', 'International Business Machines', 'IBM Corp' produce the same key for deduplication and record linkage. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Company Name Similarity Key"),
 			mcp.WithString("company", mcp.Required(), mcp.Description("Company or organization name")),
-			mcp.WithString("algorithm", mcp.Description("Algorithm variant (optional, e.g. 'ai-deep')")),
-		),
-		genericHandler("/getcompanymatchadvanced",
-			[]paramMapping{same("company")},
-			[]paramMapping{same("algorithm")},
+			withAlgorithm("interzoid_company_match_advanced"),
 		),
+		"/getcompanymatchadvanced",
+		[]paramMapping{same("company")},
+		[]paramMapping{algorithmParam("interzoid_company_match_advanced")},
 	)
 
 	// /getfullnamematch?fullname=[name]
-	s.AddTool(
+	addAPITool(s, catalog, catMatching,
 		mcp.NewTool("interzoid_fullname_match",
 			mcp.WithDescription("Generate an AI-powered similarity key for individual/person name matching. Handles variations like 'Bob Smith', 'Robert Smith', 'Smith, Robert J.' producing the same key. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Person Name Similarity Key"),
 			mcp.WithString("fullname", mcp.Required(), mcp.Description("Full individual name")),
 		),
-		genericHandler("/getfullnamematch",
-			[]paramMapping{same("fullname")},
-			nil,
-		),
+		"/getfullnamematch",
+		[]paramMapping{same("fullname")},
+		nil,
 	)
 
 	// /getaddressmatchadvanced?address=[addr]&algorithm=[algo]
-	s.AddTool(
+	addAPITool(s, catalog, catMatching,
 		mcp.NewTool("interzoid_address_match_advanced",
 			mcp.WithDescription("Generate an advanced AI-powered similarity key for US street address matching. Handles unit numbers, directionals, and abbreviations. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("US Address Similarity Key"),
 			mcp.WithString("address", mcp.Required(), mcp.Description("Street address")),
-			mcp.WithString("algorithm", mcp.Description("Algorithm variant (optional)")),
-		),
-		genericHandler("/getaddressmatchadvanced",
-			[]paramMapping{same("address")},
-			[]paramMapping{same("algorithm")},
+			withAlgorithm("interzoid_address_match_advanced"),
 		),
+		"/getaddressmatchadvanced",
+		[]paramMapping{same("address")},
+		[]paramMapping{algorithmParam("interzoid_address_match_advanced")},
 	)
 
 	// /getglobaladdressmatch?address=[addr] (uses same endpoint path but different matching)
-	s.AddTool(
+	addAPITool(s, catalog, catMatching,
 		mcp.NewTool("interzoid_global_address_match",
 			mcp.WithDescription("Generate an AI-powered similarity key for global/international address matching. Handles international address formats and variations across countries. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Global Address Similarity Key"),
 			mcp.WithString("address", mcp.Required(), mcp.Description("Full international address string")),
 		),
-		genericHandler("/getglobaladdressmatch",
-			[]paramMapping{same("address")},
-			nil,
-		),
+		"/getglobaladdressmatch",
+		[]paramMapping{same("address")},
+		nil,
 	)
 
 	// /getproductmatch?product=[name]&algorithm=[algo]
-	s.AddTool(
+	addAPITool(s, catalog, catMatching,
 		mcp.NewTool("interzoid_product_match",
 			mcp.WithDescription("Generate an AI-powered similarity key for product name matching. Handles variations in product names, model numbers, and descriptions. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Product Name Similarity Key"),
 			mcp.WithString("product", mcp.Required(), mcp.Description("Product name, description, or model")),
-			mcp.WithString("algorithm", mcp.Description("Algorithm variant (optional)")),
-		),
-		genericHandler("/getproductmatch",
-			[]paramMapping{same("product")},
-			[]paramMapping{same("algorithm")},
+			withAlgorithm("interzoid_product_match"),
 		),
+		"/getproductmatch",
+		[]paramMapping{same("product")},
+		[]paramMapping{algorithmParam("interzoid_product_match")},
 	)
 
 	// /getorgmatchscore?org1=[name1]&org2=[name2]
-	s.AddTool(
+	addAPITool(s, catalog, catMatching,
 		mcp.NewTool("interzoid_org_match_score",
 			mcp.WithDescription("Compare two organization/company names and receive a match score from 0-100 indicating similarity. Useful for determining if two company names refer to the same entity. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Organization Match Score"),
 			mcp.WithString("org1", mcp.Required(), mcp.Description("First organization name")),
 			mcp.WithString("org2", mcp.Required(), mcp.Description("Second organization name to compare")),
 		),
-		genericHandler("/getorgmatchscore",
-			[]paramMapping{same("org1"), same("org2")},
-			nil,
-		),
+		"/getorgmatchscore",
+		[]paramMapping{same("org1"), same("org2")},
+		nil,
 	)
 
 	// /getfullnamematchscore?fullname1=[name1]&fullname2=[name2]
-	s.AddTool(
+	addAPITool(s, catalog, catMatching,
 		mcp.NewTool("interzoid_fullname_match_score",
 			mcp.WithDescription("Compare two individual/person names and receive a match score from 0-100 indicating similarity. Handles name order, nicknames, and abbreviations. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Person Name Match Score"),
 			mcp.WithString("fullname1", mcp.Required(), mcp.Description("First full name")),
 			mcp.WithString("fullname2", mcp.Required(), mcp.Description("Second full name to compare")),
 		),
-		genericHandler("/getfullnamematchscore",
-			[]paramMapping{same("fullname1"), same("fullname2")},
-			nil,
-		),
+		"/getfullnamematchscore",
+		[]paramMapping{same("fullname1"), same("fullname2")},
+		nil,
 	)
 
 	// =====================================================================
@@ -225,111 +352,111 @@ func registerAllTools(s *server.MCPServer) {
 	// =====================================================================
 
 	// /getbusinessinfo?lookup=[company]
-	s.AddTool(
+	addAPITool(s, catalog, catEnrichment,
 		mcp.NewTool("interzoid_business_info",
 			mcp.WithDescription("Retrieve comprehensive AI-powered business intelligence for a company including industry, revenue, employee counts, and executive info. Premium API. Cost: $0.3125 USDC via x402."),
+			mcp.WithTitleAnnotation("Business Information"),
 			mcp.WithString("lookup", mcp.Required(), mcp.Description("Company name, website, or email")),
 		),
-		genericHandler("/getbusinessinfo",
-			[]paramMapping{same("lookup")},
-			nil,
-		),
+		"/getbusinessinfo",
+		[]paramMapping{same("lookup")},
+		nil,
 	)
 
 	// /getparentcompanyinfo?lookup=[company name or domain]
-	s.AddTool(
+	addAPITool(s, catalog, catEnrichment,
 		mcp.NewTool("interzoid_parent_company_info",
 			mcp.WithDescription("Retrieve parent company information for a given company or subsidiary. Identifies corporate ownership hierarchies and holding company relationships. Premium API. Cost: $0.3125 USDC via x402."),
+			mcp.WithTitleAnnotation("Parent Company Lookup"),
 			mcp.WithString("lookup", mcp.Required(), mcp.Description("Company name or domain to find parent company for")),
 		),
-		genericHandler("/getparentcompanyinfo",
-			[]paramMapping{same("lookup")},
-			nil,
-		),
+		"/getparentcompanyinfo",
+		[]paramMapping{same("lookup")},
+		nil,
 	)
 
 	// /getexecutiveprofile?lookup=[company and title]
-	s.AddTool(
+	addAPITool(s, catalog, catEnrichment,
 		mcp.NewTool("interzoid_executive_profile",
 			mcp.WithDescription("Retrieve executive profile information for a company including leadership details, roles, and professional background. Premium API. Cost: $0.3125 USDC via x402."),
+			mcp.WithTitleAnnotation("Executive Profile"),
 			mcp.WithString("lookup", mcp.Required(), mcp.Description("Company name and job title (e.g. 'Coinbase CEO')")),
 		),
-		genericHandler("/getexecutiveprofile",
-			[]paramMapping{same("lookup")},
-			nil,
-		),
+		"/getexecutiveprofile",
+		[]paramMapping{same("lookup")},
+		nil,
 	)
 
 	// /getrecentnews?topic=[topic]
-	s.AddTool(
+	addAPITool(s, catalog, catEnrichment,
 		mcp.NewTool("interzoid_recent_news",
 			mcp.WithDescription("Retrieve recent news and developments for a company or topic. AI-powered aggregation from multiple real-time sources. Premium API. Cost: $0.3125 USDC via x402."),
+			mcp.WithTitleAnnotation("Recent News"),
 			mcp.WithString("topic", mcp.Required(), mcp.Description("Company name or topic to get news for")),
 		),
-		genericHandler("/getrecentnews",
-			[]paramMapping{same("topic")},
-			nil,
-		),
+		"/getrecentnews",
+		[]paramMapping{same("topic")},
+		nil,
 	)
 
 	// /emailtrustscore?lookup=[email address]
-	s.AddTool(
+	addAPITool(s, catalog, catEnrichment,
 		mcp.NewTool("interzoid_email_trust_score",
 			mcp.WithDescription("Get an email trust score (0-99) and AI-generated risk analysis. Validates deliverability, identifies disposable addresses, and assesses legitimacy. Premium API. Cost: $0.3125 USDC via x402."),
+			mcp.WithTitleAnnotation("Email Trust Score"),
 			mcp.WithString("lookup", mcp.Required(), mcp.Description("Email address to score and validate")),
 		),
-		genericHandler("/emailtrustscore",
-			[]paramMapping{same("lookup")},
-			nil,
-		),
+		"/emailtrustscore",
+		[]paramMapping{same("lookup", maxLength(254), emailAddress)},
+		nil,
 	)
 
 	// /getipprofile?lookup=[ip]
-	s.AddTool(
+	addAPITool(s, catalog, catEnrichment,
 		mcp.NewTool("interzoid_ip_profile",
 			mcp.WithDescription("Get comprehensive profile for an IP address including geolocation, ISP, organization, CIDR block, and reputation assessment. Premium API. Cost: $0.3125 USDC via x402."),
+			mcp.WithTitleAnnotation("IP Address Profile"),
 			mcp.WithString("lookup", mcp.Required(), mcp.Description("IPv4 or IPv6 address to profile")),
 		),
-		genericHandler("/getipprofile",
-			[]paramMapping{same("lookup")},
-			nil,
-		),
+		"/getipprofile",
+		[]paramMapping{same("lookup", ipAddress)},
+		nil,
 	)
 
 	// /getphoneprofile?lookup=[phone]
-	s.AddTool(
+	addAPITool(s, catalog, catEnrichment,
 		mcp.NewTool("interzoid_phone_profile",
 			mcp.WithDescription("Get profile for a phone number including carrier, line type, geographic location, validation status, and risk assessment. Premium API. Cost: $0.3125 USDC via x402."),
+			mcp.WithTitleAnnotation("Phone Number Profile"),
 			mcp.WithString("lookup", mcp.Required(), mcp.Description("Phone number to profile")),
 		),
-		genericHandler("/getphoneprofile",
-			[]paramMapping{same("lookup")},
-			nil,
-		),
+		"/getphoneprofile",
+		[]paramMapping{same("lookup", phoneNumber)},
+		nil,
 	)
 
 	// /getcompanyverification?lookup=[company]
-	s.AddTool(
+	addAPITool(s, catalog, catEnrichment,
 		mcp.NewTool("interzoid_company_verification",
 			mcp.WithDescription("Verify whether a company exists and get a verification score (0-99) with AI-generated reasoning about legitimacy and credibility. Premium API. Cost: $0.3125 USDC via x402."),
+			mcp.WithTitleAnnotation("Company Verification"),
 			mcp.WithString("lookup", mcp.Required(), mcp.Description("Company or organization name to verify")),
 		),
-		genericHandler("/getcompanyverification",
-			[]paramMapping{same("lookup")},
-			nil,
-		),
+		"/getcompanyverification",
+		[]paramMapping{same("lookup")},
+		nil,
 	)
 
 	// /getstockinfo?lookup=[ticker]
-	s.AddTool(
+	addAPITool(s, catalog, catEnrichment,
 		mcp.NewTool("interzoid_stock_info",
 			mcp.WithDescription("Get AI-powered stock analysis for a ticker symbol including price, market cap, P/E ratio, EPS, and analyst assessment. Premium API. Cost: $0.3125 USDC via x402."),
+			mcp.WithTitleAnnotation("Stock Analysis"),
 			mcp.WithString("lookup", mcp.Required(), mcp.Description("Stock ticker symbol or company name (e.g. 'AAPL', 'COIN')")),
 		),
-		genericHandler("/getstockinfo",
-			[]paramMapping{same("lookup")},
-			nil,
-		),
+		"/getstockinfo",
+		[]paramMapping{same("lookup")},
+		nil,
 	)
 
 	// =====================================================================
@@ -337,67 +464,67 @@ func registerAllTools(s *server.MCPServer) {
 	// =====================================================================
 
 	// /getorgstandard?org=[name]
-	s.AddTool(
+	addAPITool(s, catalog, catStandardization,
 		mcp.NewTool("interzoid_org_standard",
 			mcp.WithDescription("Standardize an organization name to its canonical form. Normalizes abbreviations, suffixes, and formatting (e.g. 'b.o.a.' -> 'Bank of America'). Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Standardize Organization Name"),
 			mcp.WithString("org", mcp.Required(), mcp.Description("Organization name to standardize")),
 		),
-		genericHandler("/getorgstandard",
-			[]paramMapping{same("org")},
-			nil,
-		),
+		"/getorgstandard",
+		[]paramMapping{same("org")},
+		nil,
 	)
 
 	// /getcountrystandard?country=[name]&algorithm=[algo]
-	s.AddTool(
+	addAPITool(s, catalog, catStandardization,
 		mcp.NewTool("interzoid_country_standard",
 			mcp.WithDescription("Standardize a country name to a consistent canonical form. Handles variations like 'Great Britain', 'UK', 'United Kingdom'. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Standardize Country Name"),
 			mcp.WithString("country", mcp.Required(), mcp.Description("Country name to standardize")),
-			mcp.WithString("algorithm", mcp.Description("Algorithm variant (optional)")),
-		),
-		genericHandler("/getcountrystandard",
-			[]paramMapping{same("country")},
-			[]paramMapping{same("algorithm")},
+			withAlgorithm("interzoid_country_standard"),
 		),
+		"/getcountrystandard",
+		[]paramMapping{same("country")},
+		[]paramMapping{algorithmParam("interzoid_country_standard")},
 	)
 
 	// /getcountryinfo?country=[name]&algorithm=ai-medium
-	s.AddTool(
+	addAPITool(s, catalog, catStandardization,
 		mcp.NewTool("interzoid_country_info",
 			mcp.WithDescription("Standardize a country name and return comprehensive info: ISO codes (2/3-letter, 3-digit), currency details, internet code, and calling code. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Country Information"),
 			mcp.WithString("country", mcp.Required(), mcp.Description("Country name in any language or format")),
-			mcp.WithString("algorithm", mcp.Description("Algorithm variant (optional, defaults to 'ai-medium')")),
-		),
-		genericHandler("/getcountryinfo",
-			[]paramMapping{same("country")},
-			[]paramMapping{same("algorithm")},
+			withAlgorithm("interzoid_country_info"),
 		),
+		"/getcountryinfo",
+		[]paramMapping{same("country")},
+		[]paramMapping{algorithmParam("interzoid_country_info")},
 	)
 
 	// /getstateabbreviation?state=[name]&algorithm=[algo]
-	s.AddTool(
+	addAPITool(s, catalog, catStandardization,
 		mcp.NewTool("interzoid_state_abbreviation",
 			mcp.WithDescription("Standardize US state/province names to full name plus abbreviation. Handles 'Calif', 'CA', 'Cal' -> 'California' / 'CA'. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Standardize State / Province"),
 			mcp.WithString("state", mcp.Required(), mcp.Description("State or province name/abbreviation")),
-			mcp.WithString("algorithm", mcp.Description("Algorithm variant (optional)")),
-		),
-		genericHandler("/getstateabbreviation",
-			[]paramMapping{same("state")},
-			[]paramMapping{same("algorithm")},
+			withAlgorithm("interzoid_state_abbreviation"),
 		),
+		"/getstateabbreviation",
+		[]paramMapping{same("state")},
+		[]paramMapping{algorithmParam("interzoid_state_abbreviation")},
 	)
 
 	// /getcitystandard?city=[name]&algorithm=[algo]
-	s.AddTool(
+	addAPITool(s, catalog, catStandardization,
 		mcp.NewTool("interzoid_city_standard",
 			mcp.WithDescription("Standardize city name data to a consistent canonical form. Handles abbreviations, alternate spellings, and local variations. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Standardize City Name"),
 			mcp.WithString("city", mcp.Required(), mcp.Description("City name to standardize")),
-			mcp.WithString("algorithm", mcp.Description("Algorithm variant (optional)")),
-		),
-		genericHandler("/getcitystandard",
-			[]paramMapping{same("city")},
-			[]paramMapping{same("algorithm")},
+			withAlgorithm("interzoid_city_standard"),
 		),
+		"/getcitystandard",
+		[]paramMapping{same("city")},
+		[]paramMapping{algorithmParam("interzoid_city_standard")},
 	)
 
 	// =====================================================================
@@ -405,88 +532,88 @@ func registerAllTools(s *server.MCPServer) {
 	// =====================================================================
 
 	// /getentitytype?data=[text]
-	s.AddTool(
+	addAPITool(s, catalog, catEnhancement,
 		mcp.NewTool("interzoid_entity_type",
 			mcp.WithDescription("Determine the entity type of a data value - whether it represents a person, company/organization, location, or other entity type. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Entity Type"),
 			mcp.WithString("data", mcp.Required(), mcp.Description("Text data value to classify")),
 		),
-		genericHandler("/getentitytype",
-			[]paramMapping{same("data")},
-			nil,
-		),
+		"/getentitytype",
+		[]paramMapping{same("data")},
+		nil,
 	)
 
 	// /getgender?name=[first name]
-	s.AddTool(
+	addAPITool(s, catalog, catEnhancement,
 		mcp.NewTool("interzoid_gender",
 			mcp.WithDescription("Determine the likely gender associated with an individual name. Supports international names. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Gender from First Name"),
 			mcp.WithString("name", mcp.Required(), mcp.Description("First name to determine gender for")),
 		),
-		genericHandler("/getgender",
-			[]paramMapping{same("name")},
-			nil,
-		),
+		"/getgender",
+		[]paramMapping{same("name")},
+		nil,
 	)
 
 	// /getnameorigin?name=[full name]
-	s.AddTool(
+	addAPITool(s, catalog, catEnhancement,
 		mcp.NewTool("interzoid_name_origin",
 			mcp.WithDescription("Determine the likely cultural or geographic origin of an individual name. Useful for demographic analysis and internationalization. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Name Origin"),
 			mcp.WithString("name", mcp.Required(), mcp.Description("Full name to determine origin for")),
 		),
-		genericHandler("/getnameorigin",
-			[]paramMapping{same("name")},
-			nil,
-		),
+		"/getnameorigin",
+		[]paramMapping{same("name")},
+		nil,
 	)
 
 	// /identifylanguage?text=[text]
-	s.AddTool(
+	addAPITool(s, catalog, catEnhancement,
 		mcp.NewTool("interzoid_identify_language",
 			mcp.WithDescription("Identify the language of a given text string. Supports detection of numerous world languages. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Identify Language"),
 			mcp.WithString("text", mcp.Required(), mcp.Description("Text snippet to identify the language of")),
 		),
-		genericHandler("/identifylanguage",
-			[]paramMapping{same("text")},
-			nil,
-		),
+		"/identifylanguage",
+		[]paramMapping{same("text")},
+		nil,
 	)
 
 	// /translatetoenglish?text=[text]
-	s.AddTool(
+	addAPITool(s, catalog, catEnhancement,
 		mcp.NewTool("interzoid_translate_to_english",
 			mcp.WithDescription("Detect the language of input text and translate it to English. AI-powered translation supporting numerous world languages. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Translate to English"),
 			mcp.WithString("text", mcp.Required(), mcp.Description("Text in any language to translate to English")),
 		),
-		genericHandler("/translatetoenglish",
-			[]paramMapping{same("text")},
-			nil,
-		),
+		"/translatetoenglish",
+		[]paramMapping{same("text")},
+		nil,
 	)
 
 	// /translatetoany?text=[text]&to=[target language]
-	s.AddTool(
+	addAPITool(s, catalog, catEnhancement,
 		mcp.NewTool("interzoid_translate_to_any",
 			mcp.WithDescription("Detect the language of input text and translate it to any specified target language. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Translate to Any Language"),
 			mcp.WithString("text", mcp.Required(), mcp.Description("Text to translate")),
 			mcp.WithString("to", mcp.Required(), mcp.Description("Target language name (e.g. 'Japanese', 'French', 'Spanish')")),
 		),
-		genericHandler("/translatetoany",
-			[]paramMapping{same("text"), same("to")},
-			nil,
-		),
+		"/translatetoany",
+		[]paramMapping{same("text"), same("to", maxLength(50))},
+		nil,
 	)
 
 	// /addressparse?address=[full address]
-	s.AddTool(
+	addAPITool(s, catalog, catEnhancement,
 		mcp.NewTool("interzoid_address_parse",
 			mcp.WithDescription("Parse a full address string into component parts: street number, street name, unit, city, state, zip code. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Parse Address"),
 			mcp.WithString("address", mcp.Required(), mcp.Description("Full address string to parse")),
 		),
-		genericHandler("/addressparse",
-			[]paramMapping{same("address")},
-			nil,
-		),
+		"/addressparse",
+		[]paramMapping{same("address")},
+		nil,
 	)
 
 	// =====================================================================
@@ -494,39 +621,74 @@ func registerAllTools(s *server.MCPServer) {
 	// =====================================================================
 
 	// /getzipcodeinfo?zip=[zipcode]
-	s.AddTool(
+	addAPITool(s, catalog, catUtility,
 		mcp.NewTool("interzoid_zipcode_info",
 			mcp.WithDescription("Get detailed info for a US ZIP code: city, state, county, timezone, area codes, latitude/longitude. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("ZIP Code Information"),
 			mcp.WithString("zip", mcp.Required(), mcp.Description("US ZIP code (5-digit)")),
 		),
-		genericHandler("/getzipcodeinfo",
-			[]paramMapping{same("zip")},
-			nil,
-		),
+		"/getzipcodeinfo",
+		[]paramMapping{same("zip", usZipCode)},
+		nil,
 	)
 
 	// /getrates?from=[currency]&to=[currency]
-	s.AddTool(
+	addAPITool(s, catalog, catUtility,
 		mcp.NewTool("interzoid_currency_rate",
 			mcp.WithDescription("Get live currency exchange rates between two currencies. Returns current mid-market rates. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Currency Exchange Rate"),
 			mcp.WithString("from", mcp.Required(), mcp.Description("Source currency code (e.g. USD, EUR, GBP)")),
 			mcp.WithString("to", mcp.Required(), mcp.Description("Target currency code (e.g. JPY, GBP, EUR)")),
 		),
-		genericHandler("/getrates",
-			[]paramMapping{same("from"), same("to")},
-			nil,
-		),
+		"/getrates",
+		[]paramMapping{same("from", currencyCode), same("to", currencyCode)},
+		nil,
 	)
 
 	// /getglobalweather?location=[city name]
-	s.AddTool(
+	addAPITool(s, catalog, catUtility,
 		mcp.NewTool("interzoid_global_weather",
 			mcp.WithDescription("Get current weather for any city worldwide including temperature (F/C), conditions, and wind speed. Cost: $0.0125 USDC via x402."),
+			mcp.WithTitleAnnotation("Global Weather"),
 			mcp.WithString("location", mcp.Required(), mcp.Description("City name (e.g. 'London', 'Tokyo', 'San Francisco')")),
 		),
-		genericHandler("/getglobalweather",
-			[]paramMapping{same("location")},
-			nil,
-		),
+		"/getglobalweather",
+		[]paramMapping{same("location")},
+		nil,
+	)
+
+	// =====================================================================
+	// SERVER STATUS (free — no upstream call)
+	// =====================================================================
+
+	s.AddTool(
+		annotateTool(mcp.NewTool("interzoid_upstream_status",
+			mcp.WithDescription("Report the health of each Interzoid API endpoint as seen by this server. Shows circuit breaker state (closed, open, half-open), recent failures, and the last upstream error. Use this to check whether an endpoint is temporarily unavailable before retrying. No cost."),
+			mcp.WithTitleAnnotation("Upstream API Status"),
+		), catServer, freeTier, false),
+		upstreamStatusHandler,
 	)
+
+	// /getremainingcredits (API key only)
+	s.AddTool(
+		annotateTool(mcp.NewTool("interzoid_remaining_credits",
+			mcp.WithDescription("Get the remaining Interzoid credit balance for the API key in use, with how many standard and premium calls it covers. Requires an API key (not available in x402 mode). No cost."),
+			mcp.WithTitleAnnotation("Remaining Credits"),
+		), catServer, freeTier, true),
+		remainingCreditsHandler,
+	)
+
+	s.AddTool(
+		annotateTool(mcp.NewTool("interzoid_usage_report",
+			mcp.WithDescription("Summarize Interzoid API usage recorded by this server: call counts, failures, payment-required responses, and estimated spend in USD. Over a remote connection only your own usage is reported. No cost."),
+			mcp.WithTitleAnnotation("Usage Report"),
+			mcp.WithString("group_by", mcp.Description("Group results by 'day', 'tool', 'caller' or 'tag' (optional, defaults to 'day')")),
+			mcp.WithString("since", mcp.Description("First day to include, YYYY-MM-DD (optional)")),
+			mcp.WithString("until", mcp.Description("Last day to include, YYYY-MM-DD (optional)")),
+			mcp.WithString("format", mcp.Description("Output format: 'json' or 'csv' (optional, defaults to 'json')")),
+		), catServer, freeTier, false),
+		usageReportHandler,
+	)
+
+	return catalog
 }
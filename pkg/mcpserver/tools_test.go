@@ -0,0 +1,49 @@
+package mcpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// stubUpstream answers upstream API calls with handler for the rest of the
+// test, and returns the number of calls made so far.
+func stubUpstream(t *testing.T, handler http.HandlerFunc) func() int {
+	t.Helper()
+	var calls atomic.Int64
+	previous := httpClient.Transport
+	httpClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Result(), nil
+	})
+	t.Cleanup(func() { httpClient.Transport = previous })
+	return func() int { return int(calls.Load()) }
+}
+
+// callToolHandler runs the handler of a registered tool with args, as a
+// caller sending header.
+func callToolHandler(ctx context.Context, t *testing.T, name string, args map[string]interface{}, header http.Header) (*mcp.CallToolResult, error) {
+	t.Helper()
+	s := server.NewMCPServer(serverName, serverVersion)
+	registerAllTools(s)
+	tool := s.ListTools()[name]
+	if tool == nil {
+		t.Fatalf("no tool %s", name)
+	}
+	var request mcp.CallToolRequest
+	request.Params.Name = name
+	request.Params.Arguments = args
+	request.Header = header
+	return tool.Handler(ctx, request)
+}
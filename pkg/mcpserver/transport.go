@@ -0,0 +1,172 @@
+package mcpserver
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============================================================================
+// HTTP TRANSPORTS
+// ============================================================================
+//
+// The same MCPServer can be served over Streamable HTTP at /mcp, over the
+// older HTTP+SSE transport at /sse and /message, or over both on one port so
+// clients can use whichever transport they speak. Both transports pass the
+// HTTP request through httpContextFunc, so API keys, caller identification
+// and metrics behave the same way on either. The unix transport serves both
+// on a Unix domain socket instead of a TCP port, and the websocket transport
+// serves JSON-RPC over a WebSocket at /ws.
+// ============================================================================
+
+const (
+	mcpPath     = "/mcp"
+	ssePath     = "/sse"
+	messagePath = "/message"
+	metricsPath = "/debug/vars"
+)
+
+// newHTTPMux builds the HTTP handler for the http, sse, combined and
+// websocket transports, adding the REST gateway when rest is set.
+// contextFunc records who is calling in the request context.
+func newHTTPMux(s *server.MCPServer, transport string, metrics, rest bool, contextFunc server.HTTPContextFunc) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	if transport == "http" || transport == "combined" {
+		mux.Handle(mcpPath, resumableStreams(server.NewStreamableHTTPServer(s,
+			server.WithHTTPContextFunc(contextFunc),
+			server.WithSessionIdManager(httpSessions),
+		)))
+	}
+
+	if transport == "sse" || transport == "combined" {
+		sseServer := server.NewSSEServer(s,
+			server.WithSSEEndpoint(ssePath),
+			server.WithMessageEndpoint(messagePath),
+			server.WithSSEContextFunc(server.SSEContextFunc(contextFunc)),
+			server.WithKeepAlive(true),
+		)
+		mux.Handle(ssePath, sseServer.SSEHandler())
+		mux.Handle(messagePath, sseServer.MessageHandler())
+	}
+
+	if transport == "websocket" {
+		mux.Handle(wsPath, newWebSocketHandler(s))
+	}
+
+	if rest {
+		addRESTGateway(mux, s, contextFunc)
+	}
+
+	if metrics {
+		mux.Handle(metricsPath, expvar.Handler())
+	}
+	return mux
+}
+
+// logHTTPEndpoints logs where each endpoint of an HTTP transport is served.
+func logHTTPEndpoints(addr, transport string, metrics, rest bool) {
+	if transport == "http" || transport == "combined" {
+		log.Printf("MCP endpoint available at http://localhost%s%s\n", addr, mcpPath)
+	}
+	if transport == "sse" || transport == "combined" {
+		log.Printf("SSE endpoint available at http://localhost%s%s (messages at %s)\n", addr, ssePath, messagePath)
+	}
+	if transport == "websocket" {
+		log.Printf("WebSocket endpoint available at ws://localhost%s%s\n", addr, wsPath)
+	}
+	if rest {
+		log.Printf("REST gateway available at http://localhost%s%s{name} (OpenAPI at %s)\n", addr, restToolsPath, restOpenAPIPath)
+	}
+	if metrics {
+		log.Printf("Metrics available at http://localhost%s%s\n", addr, metricsPath)
+	}
+}
+
+// transportNames are the names logged for each HTTP transport.
+var transportNames = map[string]string{
+	"http":      "StreamableHTTP transport",
+	"sse":       "HTTP+SSE transport",
+	"combined":  "StreamableHTTP and HTTP+SSE transports",
+	"websocket": "WebSocket transport",
+}
+
+// serveUnix serves the Streamable HTTP and HTTP+SSE endpoints on a Unix
+// domain socket whose file mode controls which local users may connect.
+func serveUnix(s *server.MCPServer, path string, mode os.FileMode, metrics, rest bool) error {
+	listener, err := listenUnix(path, mode)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	defer os.Remove(path)
+
+	return http.Serve(listener, newHTTPMux(s, "combined", metrics, rest, localContextFunc))
+}
+
+// listenUnix listens on a Unix domain socket at path with the given mode.
+// The socket is created in a private directory and its mode set there, so
+// it is never reachable with looser permissions, then moved into place.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".interzoid-mcp-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "socket")
+	listener, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+
+	if err := os.Chmod(tmp, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to move socket into place: %w", err)
+	}
+	// The listener would unlink the temporary name on close
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	return listener, nil
+}
+
+// removeStaleSocket removes a socket left behind by a previous run. It never
+// removes a regular file, or a socket another server is still listening on.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another server", path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("failed to check socket %s: %w", path, err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	return nil
+}
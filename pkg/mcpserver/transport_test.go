@@ -0,0 +1,174 @@
+package mcpserver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const initializeRequest = `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`
+
+func TestHTTPMuxRoutes(t *testing.T) {
+	tests := []struct {
+		transport string
+		metrics   bool
+		served    []string
+		missing   []string
+	}{
+		{transport: "http", served: []string{mcpPath}, missing: []string{ssePath, messagePath, metricsPath}},
+		{transport: "sse", metrics: true, served: []string{ssePath, messagePath, metricsPath}, missing: []string{mcpPath}},
+		{transport: "combined", served: []string{mcpPath, ssePath, messagePath}, missing: []string{metricsPath}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.transport, func(t *testing.T) {
+			mux := newHTTPMux(server.NewMCPServer(serverName, serverVersion), tt.transport, tt.metrics, false, httpContextFunc)
+			for _, path := range tt.served {
+				if _, pattern := mux.Handler(httptest.NewRequest("GET", path, nil)); pattern != path {
+					t.Errorf("%s not served", path)
+				}
+			}
+			for _, path := range tt.missing {
+				if _, pattern := mux.Handler(httptest.NewRequest("GET", path, nil)); pattern != "" {
+					t.Errorf("%s served", path)
+				}
+			}
+		})
+	}
+}
+
+// A combined server answers Streamable HTTP at /mcp and opens SSE streams
+// at /sse that announce their message endpoint.
+func TestCombinedTransport(t *testing.T) {
+	ts := httptest.NewServer(newHTTPMux(server.NewMCPServer(serverName, serverVersion), "combined", false, false, httpContextFunc))
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+mcpPath, "application/json", strings.NewReader(initializeRequest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Mcp-Session-Id") == "" {
+		t.Errorf("initialize over /mcp = %d, session %q", resp.StatusCode, resp.Header.Get("Mcp-Session-Id"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL+ssePath, nil)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+			if !strings.Contains(data, messagePath+"?sessionId=") {
+				t.Errorf("endpoint event = %q", data)
+			}
+			return
+		}
+	}
+	t.Fatalf("no endpoint event: %v", scanner.Err())
+}
+
+func TestListenUnix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mcp.sock")
+
+	listener, err := listenUnix(path, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0600 {
+		t.Fatalf("socket = %v, %v, want a socket with mode 0600", info, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("socket directory left behind: %v", entries)
+	}
+
+	// A socket still being listened on is left alone
+	if _, err := listenUnix(path, 0600); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("second listener error = %v, want in use", err)
+	}
+
+	// Once its server has gone, the stale socket is replaced
+	listener.Close()
+	listener, err = listenUnix(path, 0660)
+	if err != nil {
+		t.Fatalf("replacing a stale socket: %v", err)
+	}
+	listener.Close()
+}
+
+func TestListenUnixRefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.sock")
+	os.WriteFile(path, []byte("keep"), 0600)
+
+	if _, err := listenUnix(path, 0600); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Errorf("error = %v, want not a socket", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "keep" {
+		t.Error("the regular file was replaced")
+	}
+}
+
+// WebSocket calls are authenticated with the headers of the upgrade request.
+func TestWebSocketTransport(t *testing.T) {
+	var apiKey string
+	stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		apiKey = r.Header.Get("x-api-key")
+		w.Write([]byte(`{"Gender":"F","Code":"Success"}`))
+	})
+
+	s := server.NewMCPServer(serverName, serverVersion,
+		server.WithToolCapabilities(false),
+		server.WithToolHandlerMiddleware(withConnectionHeaders),
+	)
+	registerAllTools(s)
+	ts := httptest.NewServer(newHTTPMux(s, "websocket", false, false, httpContextFunc))
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + wsPath
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Authorization": {"Bearer ws-key"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	conn.WriteMessage(websocket.TextMessage, []byte(initializeRequest))
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+	conn.WriteMessage(websocket.TextMessage, []byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"interzoid_gender","arguments":{"name":"Maria"}}}`))
+
+	for {
+		var response struct {
+			ID     int             `json:"id"`
+			Result json.RawMessage `json:"result"`
+		}
+		if err := conn.ReadJSON(&response); err != nil {
+			t.Fatal(err)
+		}
+		if response.ID != 2 {
+			continue
+		}
+		if !strings.Contains(string(response.Result), `\"Gender\": \"F\"`) {
+			t.Errorf("tools/call result = %s", response.Result)
+		}
+		break
+	}
+	if apiKey != "ws-key" {
+		t.Errorf("upstream API key = %q, want the upgrade request's key", apiKey)
+	}
+}
@@ -0,0 +1,188 @@
+package mcpserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// usageReportHandler summarizes the usage ledger. Over HTTP the report only
+// covers the requesting caller's API key, so one tenant of a hosted instance
+// cannot see another's usage, and anonymous remote callers are refused; over
+// stdio the local operator sees everything.
+func usageReportHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	if usageLedger == nil {
+		return toolErrorResult(&toolError{
+			code:    codeInternal,
+			message: "Usage ledger is disabled on this server",
+			hint:    "Start the server with -ledger <path> to record usage.",
+		}), nil
+	}
+
+	args := getArguments(request)
+	str := func(name, def string) string {
+		if s, ok := args[name].(string); ok && s != "" {
+			return s
+		}
+		return def
+	}
+
+	groupBy := str("group_by", "day")
+	format := str("format", "json")
+
+	filter, err := reportFilter(str("since", ""), str("until", ""))
+	if err != nil {
+		return toolErrorResult(newToolError(codeInvalidInput, "%v", err)), nil
+	}
+	// A remote caller only sees its own usage, which needs an identity of
+	// its own: anonymous callers sharing an address or a proxy would see
+	// each other's calls
+	if isRemote(ctx) {
+		if headerAPIKey(request) == "" {
+			return toolErrorResult(&toolError{
+				code:    codeAuthFailed,
+				message: "The usage report is only available to remote callers with an API key",
+				hint:    "Send your Interzoid API key as Authorization: Bearer <key>.",
+			}), nil
+		}
+		filter.caller = callerID(ctx, request)
+	}
+
+	report, err := usageLedger.summarize(groupBy, filter)
+	if err != nil {
+		return toolErrorResult(err), nil
+	}
+
+	switch format {
+	case "csv":
+		var buf bytes.Buffer
+		if err := writeUsageCSV(&buf, groupBy, report); err != nil {
+			return toolErrorResult(fmt.Errorf("failed to format response: %w", err)), nil
+		}
+		return mcp.NewToolResultText(buf.String()), nil
+
+	case "json":
+		jsonBytes, err := json.MarshalIndent(map[string]interface{}{
+			"groupBy": groupBy,
+			"rows":    report,
+		}, "", "  ")
+		if err != nil {
+			return toolErrorResult(fmt.Errorf("failed to format response: %w", err)), nil
+		}
+		return mcp.NewToolResultText(string(jsonBytes)), nil
+
+	default:
+		return toolErrorResult(newToolError(codeInvalidInput, "Invalid format %q (use json or csv)", format)), nil
+	}
+}
+
+// reportFilter builds a usage filter from YYYY-MM-DD bounds. Both bounds are
+// inclusive days.
+func reportFilter(since, until string) (usageFilter, error) {
+	var f usageFilter
+	var err error
+	if f.since, err = parseReportDate("since", since); err != nil {
+		return f, err
+	}
+	if f.until, err = parseReportDate("until", until); err != nil {
+		return f, err
+	}
+	if !f.until.IsZero() {
+		f.until = f.until.Add(24 * time.Hour)
+	}
+	return f, nil
+}
+
+// runUsageCommand implements the "usage" subcommand, which prints a usage
+// report from the local ledger and returns the process exit code.
+func runUsageCommand(args []string) int {
+	fs := flag.NewFlagSet("usage", flag.ContinueOnError)
+	cfg, err := loadConfigFlag(fs, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	ledgerPath := fs.String("ledger", cfg.Usage.Ledger, "Path to the SQLite usage ledger")
+	groupBy := fs.String("by", "day", "Group by: day, tool, caller or tag")
+	since := fs.String("since", "", "First day to include (YYYY-MM-DD)")
+	until := fs.String("until", "", "Last day to include (YYYY-MM-DD)")
+	caller := fs.String("caller", "", "Only include calls from this caller ID")
+	format := fs.String("format", "table", "Output format: table, csv or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	filter, err := reportFilter(*since, *until)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	filter.caller = *caller
+
+	if *ledgerPath == "" {
+		fmt.Fprintln(os.Stderr, "No usage ledger configured: pass -ledger or set usage.ledger")
+		return 2
+	}
+	if _, err := os.Stat(*ledgerPath); err != nil {
+		fmt.Fprintf(os.Stderr, "No usage ledger at %s: %v\n", *ledgerPath, err)
+		return 1
+	}
+	l, err := openLedger(*ledgerPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer l.close()
+
+	report, err := l.summarize(*groupBy, filter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	switch *format {
+	case "table":
+		err = writeUsageTable(os.Stdout, *groupBy, report)
+	case "csv":
+		err = writeUsageCSV(os.Stdout, *groupBy, report)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format: %s (use 'table', 'csv' or 'json')\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// writeUsageTable writes a usage report as an aligned text table.
+func writeUsageTable(w io.Writer, groupBy string, report []usageRow) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%s\tcalls\tok\t402\tfailed\tcached\tspend (USD)\t\n", groupBy)
+
+	var total usageRow
+	for _, r := range report {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%.4f\t\n", r.Group, r.Calls, r.Succeeded, r.PaymentRequired, r.Failed, r.Cached, r.SpendUSD)
+		total.Calls += r.Calls
+		total.Succeeded += r.Succeeded
+		total.PaymentRequired += r.PaymentRequired
+		total.Failed += r.Failed
+		total.Cached += r.Cached
+		total.SpendUSD += r.SpendUSD
+	}
+	fmt.Fprintf(tw, "total\t%d\t%d\t%d\t%d\t%d\t%.4f\t\n", total.Calls, total.Succeeded, total.PaymentRequired, total.Failed, total.Cached, total.SpendUSD)
+	return tw.Flush()
+}
@@ -0,0 +1,176 @@
+package mcpserver
+
+import (
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ============================================================================
+// PARAMETER VALIDATION & NORMALIZATION
+// ============================================================================
+//
+// Every argument is trimmed and normalized to Unicode NFC before it is sent
+// upstream, and then checked against the rules attached to its paramMapping.
+// Catching bad input here means we never pay for a call that was bound to
+// fail, and the error tells the agent exactly how to fix its input.
+// ============================================================================
+
+// paramRule validates a single argument value, returning the (possibly
+// normalized) value or an error describing what a valid value looks like.
+type paramRule func(value string) (string, error)
+
+// normalize applies the default normalization and then p's rules to a raw
+// argument value.
+func (p paramMapping) normalize(value string) (string, error) {
+	value = norm.NFC.String(strings.TrimSpace(value))
+	if value == "" {
+		return "", fmt.Errorf("must not be empty")
+	}
+
+	for _, rule := range p.rules {
+		var err error
+		if value, err = rule(value); err != nil {
+			return "", err
+		}
+	}
+	return value, nil
+}
+
+// invalidParamMessage formats a validation failure for the agent.
+func invalidParamMessage(name, value string, err error) string {
+	return fmt.Sprintf("Invalid parameter %s: %v (got %q). Correct the value and call the tool again.", name, err, value)
+}
+
+// maxLength rejects values longer than n characters.
+func maxLength(n int) paramRule {
+	return func(value string) (string, error) {
+		if utf8.RuneCountInString(value) > n {
+			return "", fmt.Errorf("must be at most %d characters", n)
+		}
+		return value, nil
+	}
+}
+
+// pattern rejects values that do not match re. hint describes a valid value.
+func pattern(re *regexp.Regexp, hint string) paramRule {
+	return func(value string) (string, error) {
+		if !re.MatchString(value) {
+			return "", fmt.Errorf("must be %s", hint)
+		}
+		return value, nil
+	}
+}
+
+// oneOf accepts only the given values, matched case-insensitively and
+// normalized to the canonical spelling.
+func oneOf(values ...string) paramRule {
+	return func(value string) (string, error) {
+		for _, v := range values {
+			if strings.EqualFold(v, value) {
+				return v, nil
+			}
+		}
+		return "", fmt.Errorf("must be one of: %s", strings.Join(values, ", "))
+	}
+}
+
+var usZipRe = regexp.MustCompile(`^\d{5}(-\d{4})?$`)
+
+// usZipCode accepts a 5-digit ZIP or ZIP+4 code.
+var usZipCode = pattern(usZipRe, "a 5-digit US ZIP code (e.g. 94105) or ZIP+4 (e.g. 94105-1804)")
+
+// emailAddress accepts a bare email address, stripping any display name.
+func emailAddress(value string) (string, error) {
+	addr, err := mail.ParseAddress(value)
+	if err == nil {
+		_, domain, _ := strings.Cut(addr.Address, "@")
+		if strings.Contains(domain, ".") {
+			return addr.Address, nil
+		}
+	}
+	return "", fmt.Errorf("must be a single email address such as jane.doe@example.com")
+}
+
+// ipAddress accepts an IPv4 or IPv6 address, returned in canonical form.
+func ipAddress(value string) (string, error) {
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return "", fmt.Errorf("must be an IPv4 address (e.g. 8.8.8.8) or IPv6 address (e.g. 2001:4860:4860::8888) without a port or CIDR suffix")
+	}
+	return addr.String(), nil
+}
+
+var phoneFormattingRe = regexp.MustCompile(`[\s().\-/]`)
+var phoneDigitsRe = regexp.MustCompile(`^\+?\d{7,15}$`)
+
+// phoneNumber accepts a phone number of 7 to 15 digits, optionally with a
+// leading + country code. Common formatting characters are stripped.
+func phoneNumber(value string) (string, error) {
+	digits := phoneFormattingRe.ReplaceAllString(value, "")
+	if !phoneDigitsRe.MatchString(digits) {
+		return "", fmt.Errorf("must be a phone number of 7-15 digits, optionally with a leading + and country code (e.g. +1 415 555 0100)")
+	}
+	return digits, nil
+}
+
+// currencyCode accepts an ISO 4217 currency code, upper-cased. When the
+// value looks like a currency name, the error suggests matching codes.
+func currencyCode(value string) (string, error) {
+	code := strings.ToUpper(value)
+	if _, ok := iso4217Currencies[code]; ok {
+		return code, nil
+	}
+
+	err := "must be a 3-letter ISO 4217 currency code such as USD, EUR or GBP"
+	if suggestions := suggestCurrencies(value); len(suggestions) > 0 {
+		err += "; did you mean " + strings.Join(suggestions, ", ") + "?"
+	}
+	return "", fmt.Errorf("%s", err)
+}
+
+// majorCurrencies are suggested ahead of other matches, in this order.
+var majorCurrencies = []string{"USD", "EUR", "GBP", "JPY", "CNY", "CAD", "AUD", "CHF", "INR", "MXN"}
+
+// suggestCurrencies returns up to five currency codes whose name contains
+// the given text, ignoring case and a trailing plural "s". Major currencies
+// are listed first.
+func suggestCurrencies(text string) []string {
+	needle := strings.TrimSuffix(strings.ToLower(text), "s")
+	if len(needle) < 3 {
+		return nil
+	}
+
+	var codes []string
+	for code, name := range iso4217Currencies {
+		if strings.Contains(strings.ToLower(name), needle) {
+			codes = append(codes, code)
+		}
+	}
+
+	rank := func(code string) int {
+		for i, c := range majorCurrencies {
+			if c == code {
+				return i
+			}
+		}
+		return len(majorCurrencies)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		ri, rj := rank(codes[i]), rank(codes[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return codes[i] < codes[j]
+	})
+	if len(codes) > 5 {
+		codes = codes[:5]
+	}
+	return codes
+}
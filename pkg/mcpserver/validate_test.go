@@ -0,0 +1,79 @@
+package mcpserver
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParamRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		param   paramMapping
+		in      string
+		want    string
+		wantErr string
+	}{
+		{"trimmed", same("text"), "  hello ", "hello", ""},
+		{"NFC", same("text"), "Café", "Café", ""},
+		{"empty", same("text"), "   ", "", "must not be empty"},
+		{"too long", same("to", maxLength(3)), "French", "", "at most 3 characters"},
+		{"zip", same("zip", usZipCode), "94105-1804", "94105-1804", ""},
+		{"bad zip", same("zip", usZipCode), "9410", "", "5-digit US ZIP code"},
+		{"email display name", same("lookup", emailAddress), "Jane <jane@example.com>", "jane@example.com", ""},
+		{"email without domain", same("lookup", emailAddress), "jane@localhost", "", "single email address"},
+		{"ipv6 canonical", same("lookup", ipAddress), "2001:4860:4860:0:0:0:0:8888", "2001:4860:4860::8888", ""},
+		{"ip with port", same("lookup", ipAddress), "8.8.8.8:53", "", "without a port"},
+		{"phone formatting", same("lookup", phoneNumber), "+1 (415) 555-0100", "+14155550100", ""},
+		{"short phone", same("lookup", phoneNumber), "555", "", "7-15 digits"},
+		{"currency case", same("from", currencyCode), "usd", "USD", ""},
+		{"currency name", same("from", currencyCode), "Euros", "", "did you mean EUR"},
+		{"one of", same("x", oneOf("Narrow", "Wide")), "wide", "Wide", ""},
+		{"not one of", same("x", oneOf("Narrow", "Wide")), "deep", "", "one of: Narrow, Wide"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.param.normalize(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("normalize(%q) error = %v, want %q", tt.in, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("normalize(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+			}
+		})
+	}
+}
+
+// Invalid arguments are refused with a tool error before any upstream
+// call; valid ones are sent normalized.
+func TestToolArgumentsValidated(t *testing.T) {
+	var query string
+	calls := stubUpstream(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"Code":"Success"}`))
+	})
+	header := http.Header{"Authorization": {"Bearer key"}}
+
+	result, err := callToolHandler(context.Background(), t, "interzoid_currency_rate", map[string]interface{}{"from": "dollars", "to": "EUR"}, header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError || !strings.Contains(resultText(result), "Invalid parameter from") {
+		t.Errorf("result = %s, want an invalid parameter error", resultText(result))
+	}
+	if calls() != 0 {
+		t.Error("the invalid call reached the API")
+	}
+
+	result, err = callToolHandler(context.Background(), t, "interzoid_currency_rate", map[string]interface{}{"from": " usd", "to": "eur "}, header)
+	if err != nil || result.IsError {
+		t.Fatalf("valid call failed: %v %s", err, resultText(result))
+	}
+	if !strings.Contains(query, "from=USD") || !strings.Contains(query, "to=EUR") {
+		t.Errorf("upstream query = %q, want normalized currency codes", query)
+	}
+}
@@ -0,0 +1,136 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ============================================================================
+// WEBSOCKET TRANSPORT
+// ============================================================================
+//
+// Each WebSocket connection is one MCP session. Every text frame carries one
+// JSON-RPC message; responses and server notifications are sent back as text
+// frames on the same connection. Requests are handled concurrently, so a slow
+// upstream lookup does not hold up other calls on the connection.
+// ============================================================================
+
+const (
+	wsPath = "/ws"
+
+	// wsMaxMessageSize bounds a single incoming JSON-RPC message
+	wsMaxMessageSize = 1 << 20
+)
+
+var wsUpgrader = websocket.Upgrader{}
+
+// wsSession is the MCP client session of one WebSocket connection.
+type wsSession struct {
+	id            string
+	notifications chan mcp.JSONRPCNotification
+	initialized   atomic.Bool
+}
+
+func (ws *wsSession) SessionID() string { return ws.id }
+func (ws *wsSession) NotificationChannel() chan<- mcp.JSONRPCNotification {
+	return ws.notifications
+}
+func (ws *wsSession) Initialize()       { ws.initialized.Store(true) }
+func (ws *wsSession) Initialized() bool { return ws.initialized.Load() }
+
+// newWebSocketHandler returns the HTTP handler that upgrades connections to
+// the WebSocket transport.
+func newWebSocketHandler(s *server.MCPServer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := wsUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade has already written an HTTP error response
+			return
+		}
+		defer conn.Close()
+		conn.SetReadLimit(wsMaxMessageSize)
+
+		session := &wsSession{
+			id:            "ws-" + newSessionID(),
+			notifications: make(chan mcp.JSONRPCNotification, 100),
+		}
+
+		// The upgrade request's headers (Authorization, X-Interzoid-Tag) apply
+		// to every call made on the connection
+		ctx := httpContextFunc(r.Context(), r)
+		ctx = context.WithValue(ctx, connHeaderKey, r.Header.Clone())
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		if err := s.RegisterSession(ctx, session); err != nil {
+			log.Printf("WebSocket session rejected: %v\n", err)
+			return
+		}
+		defer s.UnregisterSession(ctx, session.id)
+		ctx = s.WithContext(ctx, session)
+
+		var writeMu sync.Mutex
+		write := func(v interface{}) {
+			writeMu.Lock()
+			defer writeMu.Unlock()
+			if err := conn.WriteJSON(v); err != nil {
+				cancel()
+			}
+		}
+
+		go func() {
+			for {
+				select {
+				case n := <-session.notifications:
+					write(n)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		var inFlight sync.WaitGroup
+		for {
+			kind, message, err := conn.ReadMessage()
+			if err != nil {
+				// Abandon calls still in flight; nobody is left to receive them
+				cancel()
+				inFlight.Wait()
+				return
+			}
+			if kind != websocket.TextMessage {
+				continue
+			}
+
+			inFlight.Add(1)
+			go func(message json.RawMessage) {
+				defer inFlight.Done()
+				if response := s.HandleMessage(ctx, message); response != nil {
+					write(response)
+				}
+			}(message)
+		}
+	})
+}
+
+// withConnectionHeaders gives tool calls the headers of the connection they
+// arrived on when the transport does not attach them per request, as on the
+// WebSocket transport.
+func withConnectionHeaders(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if len(request.Header) == 0 {
+			if h, ok := ctx.Value(connHeaderKey).(http.Header); ok {
+				request.Header = h
+			}
+		}
+		return next(ctx, request)
+	}
+}